@@ -0,0 +1,237 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Audit actions
+const (
+	AuditActionCheckout     = "checkout"
+	AuditActionCheckin      = "checkin"
+	AuditActionSwapCheckin  = "swap_checkin"
+	AuditActionSwapCheckout = "swap_checkout"
+	AuditActionUndoCheckout = "undo_checkout"
+	AuditActionLoan         = "loan"
+	AuditActionCreateCharge = "create_charge"
+	AuditActionPayment      = "payment"
+)
+
+// AuditEvent represents a recorded state-changing action
+type AuditEvent struct {
+	ID          int         `json:"id"`
+	Date        time.Time   `json:"date"`
+	Username    string      `json:"username"`
+	DisplayName string      `json:"display_name"`
+	Action      string      `json:"action"`
+	OtherID     string      `json:"other_id,omitempty"`
+	DeviceID    int         `json:"device_id,omitempty"`
+	BagTag      string      `json:"bag_tag,omitempty"`
+	Before      interface{} `json:"before,omitempty"`
+	After       interface{} `json:"after,omitempty"`
+	RequestID   string      `json:"request_id,omitempty"`
+}
+
+// AuditFilter limits the AuditEvents returned by GetAuditEvents. Empty fields are ignored
+type AuditFilter struct {
+	OtherID  string
+	DeviceID int
+	BagTag   string
+	Username string
+	From     *time.Time
+	To       *time.Time
+	Limit    int
+}
+
+// deviceAuditValues returns the values of d recorded in an AuditEvent, or nil if d is nil
+func deviceAuditValues(d *Device) map[string]string {
+	if d == nil {
+		return nil
+	}
+	return map[string]string{"status": d.Status, "user": d.User}
+}
+
+// nullString returns nil if s is empty, or s otherwise
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// marshalAuditValue returns v encoded as JSON, or nil if v is nil
+func marshalAuditValue(v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(buf), nil
+}
+
+// Audit records e with the current user, request ID, and time
+func Audit(ctx context.Context, e *AuditEvent) error {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	if commitUser, ok := ctx.Value(UserKey).(*User); ok && commitUser != nil {
+		e.Username = commitUser.Username
+		e.DisplayName = commitUser.DisplayName
+	}
+
+	if id, ok := ctx.Value(RequestIDKey).(string); ok {
+		e.RequestID = id
+	}
+
+	e.Date = time.Now()
+
+	before, err := marshalAuditValue(e.Before)
+	if err != nil {
+		return &Error{Description: fmt.Sprintf("Could not encode %s audit event", e.Action), Err: err}
+	}
+
+	after, err := marshalAuditValue(e.After)
+	if err != nil {
+		return &Error{Description: fmt.Sprintf("Could not encode %s audit event", e.Action), Err: err}
+	}
+
+	var deviceID interface{}
+	if e.DeviceID != 0 {
+		deviceID = e.DeviceID
+	}
+
+	res, err := tx.Exec(`
+	INSERT INTO audit_events(date, username, display_name, action, other_id, device_id, bag_tag, before_value, after_value, request_id)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		e.Date, e.Username, e.DisplayName, e.Action, nullString(e.OtherID), deviceID, nullString(e.BagTag), before, after, nullString(e.RequestID),
+	)
+	if err != nil {
+		return &Error{Description: fmt.Sprintf("Could not record %s audit event", e.Action), Err: err}
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return &Error{Description: fmt.Sprintf("Could not get %s audit event id", e.Action), Err: err}
+	}
+	e.ID = int(id)
+
+	return nil
+}
+
+// auditDevice records an audit event for action on the device with the given bagTag.
+// before is the device before the action; the device is read again to record its values after the action
+func auditDevice(ctx context.Context, action, otherID, bagTag string, before *Device) error {
+	after, err := Devices.GetDevice(ctx, bagTag)
+	if err != nil {
+		return err
+	}
+
+	e := &AuditEvent{
+		Action:  action,
+		OtherID: otherID,
+		BagTag:  bagTag,
+		Before:  deviceAuditValues(before),
+		After:   deviceAuditValues(after),
+	}
+
+	if before != nil {
+		e.DeviceID = before.ID
+	} else if after != nil {
+		e.DeviceID = after.ID
+	}
+
+	return Audit(ctx, e)
+}
+
+// GetAuditEvents returns the AuditEvents matching filter, newest first
+func GetAuditEvents(ctx context.Context, filter *AuditFilter) ([]*AuditEvent, error) {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	query := `
+	SELECT id, date, username, display_name, action, other_id, device_id, bag_tag, before_value, after_value, request_id
+	FROM audit_events
+	WHERE 1=1`
+	var args []interface{}
+
+	if filter.OtherID != "" {
+		query += " AND other_id = ?"
+		args = append(args, filter.OtherID)
+	}
+
+	if filter.DeviceID != 0 {
+		query += " AND device_id = ?"
+		args = append(args, filter.DeviceID)
+	}
+
+	if filter.BagTag != "" {
+		query += " AND bag_tag = ?"
+		args = append(args, filter.BagTag)
+	}
+
+	if filter.Username != "" {
+		query += " AND username = ?"
+		args = append(args, filter.Username)
+	}
+
+	if filter.From != nil {
+		query += " AND date >= ?"
+		args = append(args, *filter.From)
+	}
+
+	if filter.To != nil {
+		query += " AND date <= ?"
+		args = append(args, *filter.To)
+	}
+
+	query += " ORDER BY date DESC, id DESC"
+
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := tx.Query(query+";", args...)
+	if err != nil {
+		return nil, &Error{Description: "Could not query Audit Event list", Err: err}
+	}
+	defer rows.Close()
+
+	var events []*AuditEvent
+
+	for rows.Next() {
+		var (
+			e                                   = new(AuditEvent)
+			otherID, bagTag, before, after, rid *string
+			deviceID                            *int
+		)
+
+		if err := rows.Scan(&(e.ID), &(e.Date), &(e.Username), &(e.DisplayName), &(e.Action), &otherID, &deviceID, &bagTag, &before, &after, &rid); err != nil {
+			return nil, &Error{Description: "Could not scan Audit Event row", Err: err}
+		}
+
+		e.OtherID = deref(otherID)
+		e.BagTag = deref(bagTag)
+		e.RequestID = deref(rid)
+		if deviceID != nil {
+			e.DeviceID = *deviceID
+		}
+		if before != nil {
+			e.Before = json.RawMessage(*before)
+		}
+		if after != nil {
+			e.After = json.RawMessage(*after)
+		}
+
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &Error{Description: "Could not scan Audit Event rows", Err: err}
+	}
+
+	return events, nil
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestAuditEvents(t *testing.T) {
+	ctx := context.WithValue(oneRosterContext(t), RequestIDKey, "req-1")
+	exec(t, ctx, "INSERT INTO devices(Inventory_Number, Serial_Number, Bag_Tag, Status, User, Model) VALUES ('INV1', 'SER1', '1001', 'Storage', '', 'Chromebook');")
+
+	if _, err := CheckoutDevice(ctx, "100001", "1001", ""); err != nil {
+		t.Fatal("CheckoutDevice returned an error:", err)
+	}
+	if err := CheckinDevice(ctx, "100001", "1001", false, nil, ""); err != nil {
+		t.Fatal("CheckinDevice returned an error:", err)
+	}
+
+	other := context.WithValue(ctx, UserKey, &User{Username: "clerk", DisplayName: "Clerk Person", Roles: []Role{RoleCashier}})
+	if err := Audit(other, &AuditEvent{Action: AuditActionPayment, OtherID: "100002"}); err != nil {
+		t.Fatal("Audit returned an error:", err)
+	}
+
+	events, err := GetAuditEvents(ctx, &AuditFilter{})
+	if err != nil {
+		t.Fatal("GetAuditEvents returned an error:", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("GetAuditEvents returned %d events, want 3", len(events))
+	}
+
+	//newest first
+	for i, action := range []string{AuditActionPayment, AuditActionCheckin, AuditActionCheckout} {
+		if events[i].Action != action {
+			t.Errorf("event %d action = %s, want %s", i, events[i].Action, action)
+		}
+	}
+
+	checkout := events[2]
+	if checkout.Username != "tech" || checkout.DisplayName != "Tech Person" || checkout.OtherID != "100001" ||
+		checkout.BagTag != "1001" || checkout.DeviceID == 0 || checkout.RequestID != "req-1" {
+		t.Errorf("checkout event = %+v, want the user, student, device, and request ID", checkout)
+	}
+
+	var before, after map[string]string
+	if err = json.Unmarshal(checkout.Before.(json.RawMessage), &before); err != nil {
+		t.Fatal("Could not decode before value:", err)
+	}
+	if err = json.Unmarshal(checkout.After.(json.RawMessage), &after); err != nil {
+		t.Fatal("Could not decode after value:", err)
+	}
+	if before["status"] != "Storage" || before["user"] != "" || after["status"] != "Checked Out" || after["user"] != "Jane Doe" {
+		t.Errorf("checkout event before = %v, after = %v, want Storage to Checked Out by Jane Doe", before, after)
+	}
+
+	if payment := events[0]; payment.Username != "clerk" || payment.BagTag != "" || payment.DeviceID != 0 || payment.Before != nil || payment.After != nil {
+		t.Errorf("payment event = %+v, want clerk with no device or values", payment)
+	}
+
+	now := time.Now()
+	hourAgo, hourLater := now.Add(-time.Hour), now.Add(time.Hour)
+
+	for _, test := range []struct {
+		name   string
+		filter *AuditFilter
+		want   int
+	}{
+		{"other_id", &AuditFilter{OtherID: "100001"}, 2},
+		{"device_id", &AuditFilter{DeviceID: checkout.DeviceID}, 2},
+		{"bag_tag", &AuditFilter{BagTag: "1001"}, 2},
+		{"username", &AuditFilter{Username: "clerk"}, 1},
+		{"from and to", &AuditFilter{From: &hourAgo, To: &hourLater}, 3},
+		{"from", &AuditFilter{From: &hourLater}, 0},
+		{"to", &AuditFilter{To: &hourAgo}, 0},
+		{"combined", &AuditFilter{OtherID: "100001", Username: "clerk"}, 0},
+		{"limit", &AuditFilter{Limit: 1}, 1},
+	} {
+		events, err := GetAuditEvents(ctx, test.filter)
+		if err != nil {
+			t.Fatalf("%s: GetAuditEvents returned an error: %v", test.name, err)
+		}
+		if len(events) != test.want {
+			t.Errorf("%s: GetAuditEvents returned %d events, want %d", test.name, len(events), test.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// BatchCheckout represents a single device checkout in a batch
+type BatchCheckout struct {
+	OtherID string `json:"other_id"`
+	BagTag  string `json:"bag_tag"`
+	Note    string `json:"note,omitempty"`
+}
+
+// BatchResult represents the result of a single device checkout in a batch
+type BatchResult struct {
+	OtherID string `json:"other_id"`
+	BagTag  string `json:"bag_tag"`
+	Status  string `json:"status"`
+	//CheckoutID is the id of the checkout if it succeeded
+	CheckoutID int    `json:"checkout_id,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Batch result statuses
+const (
+	BatchStatusOK    = "OK"
+	BatchStatusError = "Error"
+)
+
+// savepoint runs f inside a savepoint on the inventory transaction, rolling back to the savepoint if f returns an error
+func savepoint(ctx context.Context, name string, f func() error) error {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	if _, err := tx.Exec("SAVEPOINT " + name); err != nil {
+		return &Error{Description: fmt.Sprintf("Could not create savepoint %s", name), Err: err}
+	}
+
+	if fErr := f(); fErr != nil {
+		if _, err := tx.Exec("ROLLBACK TO SAVEPOINT " + name); err != nil {
+			return &Error{Description: fmt.Sprintf("Could not rollback to savepoint %s", name), Err: err}
+		}
+		//rolling back doesn't remove the savepoint
+		if _, err := tx.Exec("RELEASE SAVEPOINT " + name); err != nil {
+			return &Error{Description: fmt.Sprintf("Could not release savepoint %s", name), Err: err}
+		}
+		return fErr
+	}
+
+	if _, err := tx.Exec("RELEASE SAVEPOINT " + name); err != nil {
+		return &Error{Description: fmt.Sprintf("Could not release savepoint %s", name), Err: err}
+	}
+
+	return nil
+}
+
+// CheckoutDevices runs CheckoutDevice for each of the given checkouts, returning a result for each.
+// Checkouts that fail validation are rolled back individually. If bestEffort is false and any checkout fails,
+// the results are returned with a request error, and the caller should roll back the whole transaction.
+// If a server error occurs, the batch is stopped and the error is returned
+func CheckoutDevices(ctx context.Context, checkouts []*BatchCheckout, bestEffort bool) ([]*BatchResult, error) {
+	if len(checkouts) == 0 {
+		return nil, &Error{Description: "Batch has no checkouts", Err: nil, RequestError: true}
+	}
+
+	results := make([]*BatchResult, 0, len(checkouts))
+	failed := 0
+
+	for i, c := range checkouts {
+		if c == nil {
+			return nil, &Error{Description: "Batch checkout can't be empty", Err: nil, RequestError: true}
+		}
+
+		result := &BatchResult{OtherID: c.OtherID, BagTag: c.BagTag, Status: BatchStatusOK}
+
+		err := savepoint(ctx, fmt.Sprintf("batch_checkout_%d", i), func() error {
+			id, err := CheckoutDevice(ctx, c.OtherID, c.BagTag, c.Note)
+			result.CheckoutID = id
+			return err
+		})
+
+		if err != nil {
+			e, ok := err.(*Error)
+			if !ok || !e.RequestError {
+				return nil, err
+			}
+			result.Status = BatchStatusError
+			result.CheckoutID = 0
+			result.Error = e.Description
+			failed++
+		}
+
+		results = append(results, result)
+	}
+
+	if failed > 0 && !bestEffort {
+		//the whole batch will be rolled back, so no checkouts were made
+		for _, r := range results {
+			r.CheckoutID = 0
+		}
+		return results, &Error{Description: fmt.Sprintf("%d of %d checkouts failed", failed, len(checkouts)), Err: nil, RequestError: true}
+	}
+
+	return results, nil
+}
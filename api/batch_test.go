@@ -0,0 +1,87 @@
+package api
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestCheckoutDevicesBestEffort(t *testing.T) {
+	ctx := oneRosterContext(t)
+	for _, bagTag := range []string{"1001", "1002", "1003"} {
+		exec(t, ctx, "INSERT INTO devices(Bag_Tag, Status, User) VALUES (?, 'Storage', '');", bagTag)
+	}
+
+	results, err := CheckoutDevices(ctx, []*BatchCheckout{
+		{OtherID: "100001", BagTag: "1001"},
+		//Jane Doe already has a device checked out
+		{OtherID: "100001", BagTag: "1002"},
+		{OtherID: "100002", BagTag: "1003"},
+	}, true)
+	if err != nil {
+		t.Fatal("CheckoutDevices returned an error:", err)
+	}
+
+	for i, want := range []string{BatchStatusOK, BatchStatusError, BatchStatusOK} {
+		if results[i].Status != want || (want == BatchStatusOK) != (results[i].CheckoutID != 0) {
+			t.Errorf("result %d = %+v, want %s", i, results[i], want)
+		}
+	}
+
+	for bagTag, want := range map[string]string{"1001": "Jane Doe", "1002": "", "1003": "John Roe"} {
+		d, err := Devices.GetDevice(ctx, bagTag)
+		if err != nil {
+			t.Fatal("GetDevice returned an error:", err)
+		}
+		if d.User != want {
+			t.Errorf("Device %s User = %q, want %q", bagTag, d.User, want)
+		}
+	}
+
+}
+
+func TestCheckoutDevicesAllOrNothing(t *testing.T) {
+	ctx := oneRosterContext(t)
+	exec(t, ctx, "INSERT INTO devices(Bag_Tag, Status, User) VALUES ('1001', 'Storage', '');")
+
+	results, err := CheckoutDevices(ctx, []*BatchCheckout{
+		{OtherID: "100001", BagTag: "1001"},
+		{OtherID: "100002", BagTag: "9999"},
+	}, false)
+	if e, ok := err.(*Error); !ok || !e.RequestError {
+		t.Fatalf("CheckoutDevices = %v, want a request error", err)
+	}
+
+	//the caller rolls back the whole batch, so no checkout ids are returned
+	for i, r := range results {
+		if r.CheckoutID != 0 {
+			t.Errorf("result %d = %+v, want no checkout id", i, r)
+		}
+	}
+}
+
+func TestSavepointRollback(t *testing.T) {
+	ctx := testContext(t)
+	exec(t, ctx, "INSERT INTO devices(Bag_Tag, Status, User) VALUES ('1001', 'Storage', '');")
+
+	for i := 0; i < 3; i++ {
+		err := savepoint(ctx, "test", func() error {
+			exec(t, ctx, "UPDATE devices SET Status = 'Checked Out' WHERE Bag_Tag = '1001';")
+			return &Error{Description: "failed", RequestError: true}
+		})
+		if err == nil || err.Error() != "Client Error: failed" {
+			t.Fatalf("savepoint = %v, want the function's error", err)
+		}
+	}
+
+	d, err := Devices.GetDevice(ctx, "1001")
+	if err != nil {
+		t.Fatal("GetDevice returned an error:", err)
+	}
+	if d.Status != "Storage" {
+		t.Errorf("Status = %s, want the update rolled back", d.Status)
+	}
+
+	if err = ctx.Value(InventoryTransactionKey).(*sql.Tx).Commit(); err != nil {
+		t.Error("Could not commit after savepoints:", err)
+	}
+}
@@ -2,7 +2,7 @@ package api
 
 import (
 	"context"
-	"database/sql"
+	"fmt"
 	"strconv"
 	"strings"
 )
@@ -10,6 +10,7 @@ import (
 // Charge represents an inventory charge
 type Charge struct {
 	ID         int
+	User       string
 	AmountPaid float32
 	charges    string
 }
@@ -43,29 +44,76 @@ func (c *Charge) Description() string {
 	return strings.Join(reasons, ", ")
 }
 
-func getChargeList(ctx context.Context, name string) ([]*Charge, error) {
-	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
-
-	rows, err := tx.Query(`SELECT id, amount_paid, charges FROM charges WHERE user=?;`, name)
-	if err != nil {
-		return nil, &Error{Description: "Could not query Charge list", Err: err}
+// Items returns the itemized charges
+func (c *Charge) Items() []*Damage {
+	var items []*Damage
+	for _, charge := range strings.Split(c.charges, "|") {
+		if split := strings.Split(strings.TrimSpace(charge), ":"); len(split) == 2 {
+			amount, err := strconv.ParseFloat(strings.TrimSpace(split[1]), 32)
+			if err != nil {
+				continue
+			}
+			items = append(items, &Damage{Description: strings.TrimSpace(split[0]), Amount: float32(amount)})
+		}
 	}
-	defer rows.Close()
+	return items
+}
 
-	var charges []*Charge
+// Damage represents a single damage line item for a charge
+type Damage struct {
+	Description string  `json:"description"`
+	Amount      float32 `json:"amount"`
+}
 
-	for rows.Next() {
-		c := new(Charge)
-		if err := rows.Scan(&(c.ID), &(c.AmountPaid), &(c.charges)); err != nil {
-			return nil, &Error{Description: "Could not scan Charge row", Err: err}
+// checkDamages returns a non-empty description if any of the given damages can't be stored in a charge
+func checkDamages(damages []*Damage) string {
+	for _, d := range damages {
+		if d == nil {
+			return "Damage can't be empty"
 		}
+		if strings.TrimSpace(d.Description) == "" {
+			return "Damage description can't be empty"
+		}
+		if strings.ContainsAny(d.Description, ":|") {
+			return fmt.Sprintf(`Damage description "%s" can't contain ":" or "|"`, d.Description)
+		}
+		if d.Amount <= 0 {
+			return fmt.Sprintf(`Damage "%s" must have a positive amount`, d.Description)
+		}
+	}
+	return ""
+}
 
-		charges = append(charges, c)
+// formatDamages returns damages in the charges format parsed by AmountCharged and Description
+func formatDamages(damages []*Damage) string {
+	var charges []string
+	for _, d := range damages {
+		charges = append(charges, fmt.Sprintf("%s: %s",
+			strings.TrimSpace(d.Description),
+			strconv.FormatFloat(float64(d.Amount), 'f', 2, 32),
+		))
+	}
+	return strings.Join(charges, "|")
+}
+
+// createCharge creates a charge with the given damages to the student with the given otherID and name
+// for the device with the given bagTag
+func createCharge(ctx context.Context, otherID, bagTag, name string, damages []*Damage, note string) error {
+	charges := formatDamages(damages)
+
+	ok, err := Charges.CreateCharge(ctx, bagTag, name, charges, note)
+	if err != nil {
+		return err
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, &Error{Description: "Could not scan Charge rows", Err: err}
+	if !ok {
+		return &Error{Description: fmt.Sprintf("Device with Bag Tag %s is missing", bagTag), Err: nil, RequestError: true}
 	}
 
-	return charges, nil
+	return Audit(ctx, &AuditEvent{
+		Action:  AuditActionCreateCharge,
+		OtherID: otherID,
+		BagTag:  bagTag,
+		After:   map[string]string{"user": name, "charges": charges},
+	})
 }
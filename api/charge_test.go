@@ -0,0 +1,83 @@
+package api
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormatDamages(t *testing.T) {
+	damages := []*Damage{{Description: " Screen ", Amount: 45}, {Description: "Keyboard", Amount: 30.5}}
+
+	c := &Charge{charges: formatDamages(damages)}
+	if c.charges != "Screen: 45.00|Keyboard: 30.50" {
+		t.Errorf("formatDamages = %q, want %q", c.charges, "Screen: 45.00|Keyboard: 30.50")
+	}
+	if c.AmountCharged() != 75.5 {
+		t.Errorf("AmountCharged = %.2f, want 75.50", c.AmountCharged())
+	}
+	if c.Description() != "Screen, Keyboard" {
+		t.Errorf("Description = %q, want %q", c.Description(), "Screen, Keyboard")
+	}
+	if want := []*Damage{{Description: "Screen", Amount: 45}, {Description: "Keyboard", Amount: 30.5}}; !reflect.DeepEqual(c.Items(), want) {
+		t.Errorf("Items = %+v, want %+v", c.Items(), want)
+	}
+}
+
+func TestCheckDamages(t *testing.T) {
+	for _, damages := range [][]*Damage{
+		{nil},
+		{{Description: " ", Amount: 10}},
+		{{Description: "Screen: cracked", Amount: 10}},
+		{{Description: "Screen|Keyboard", Amount: 10}},
+		{{Description: "Screen", Amount: 0}},
+		{{Description: "Screen", Amount: -5}},
+	} {
+		if checkDamages(damages) == "" {
+			t.Errorf("checkDamages(%+v) = \"\", want a description", damages)
+		}
+	}
+
+	if s := checkDamages([]*Damage{{Description: "Screen", Amount: 45}}); s != "" {
+		t.Errorf("checkDamages of a valid damage = %q, want \"\"", s)
+	}
+}
+
+func TestCheckinDeviceDamages(t *testing.T) {
+	ctx := oneRosterContext(t)
+	exec(t, ctx, "INSERT INTO devices(Inventory_Number, Bag_Tag, Status, User) VALUES ('INV1', '1001', 'Checked Out', 'Jane Doe');")
+
+	//invalid damages are rejected before any changes
+	err := CheckinDevice(ctx, "100001", "1001", false, []*Damage{{Description: "Screen", Amount: 0}}, "")
+	if err == nil || !strings.Contains(err.Error(), "positive amount") {
+		t.Fatalf("CheckinDevice with invalid damages = %v, want error", err)
+	}
+
+	if err = CheckinDevice(ctx, "100001", "1001", false, []*Damage{{Description: "Screen", Amount: 45}, {Description: "Keyboard", Amount: 30}}, "Dropped"); err != nil {
+		t.Fatal("CheckinDevice returned an error:", err)
+	}
+
+	d, err := Devices.GetDevice(ctx, "1001")
+	if err != nil {
+		t.Fatal("GetDevice returned an error:", err)
+	}
+	if d.Status != "Needs Repair" {
+		t.Errorf("Status = %s, want Needs Repair", d.Status)
+	}
+
+	charges, err := Charges.GetCharges(ctx, "Jane Doe")
+	if err != nil {
+		t.Fatal("GetCharges returned an error:", err)
+	}
+	if len(charges) != 1 || charges[0].charges != "Screen: 45.00|Keyboard: 30.00" || charges[0].AmountPaid != 0 {
+		t.Fatalf("GetCharges = %+v, want one unpaid charge for the damages", charges)
+	}
+
+	var inventoryNumber, notes string
+	if err = ctxTx(ctx).QueryRow("SELECT Inventory_Number, Notes FROM charges WHERE id = ?;", charges[0].ID).Scan(&inventoryNumber, &notes); err != nil {
+		t.Fatal("Could not query charge:", err)
+	}
+	if inventoryNumber != "INV1" || !strings.Contains(notes, "Damage assessed on check in of Bag Tag 1001\n\tDropped") {
+		t.Errorf("charge Inventory_Number = %q, Notes = %q, want the device's and the assessment note", inventoryNumber, notes)
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// UndoWindow is how long after a checkout the user who made it can undo it
+var UndoWindow = 15 * time.Minute
+
+// Checkout represents a checkout of a device to a student, with the device's values before the checkout so it can be undone
+type Checkout struct {
+	ID             int
+	DeviceID       int
+	VerificationID int64
+	OtherID        string
+	Name           string
+	StatusType     StatusType
+	Username       string
+	DisplayName    string
+	PrevUser       string
+	PrevStatus     string
+	PrevNotes      string
+	Date           time.Time
+}
+
+// createCheckout records a checkout to student of the device in snapshot, taken before the checkout, so it can be undone.
+// The id of the checkout is returned
+func createCheckout(ctx context.Context, snapshot *Device, verificationID int64, student *Student, statusType StatusType) (int, error) {
+	commitUser := ctx.Value(UserKey).(*User)
+
+	return Checkouts.CreateCheckout(ctx, &Checkout{
+		DeviceID:       snapshot.ID,
+		VerificationID: verificationID,
+		OtherID:        student.OtherID,
+		Name:           student.Name(),
+		StatusType:     statusType,
+		Username:       commitUser.Username,
+		DisplayName:    commitUser.DisplayName,
+		PrevUser:       snapshot.User,
+		PrevStatus:     snapshot.Status,
+		PrevNotes:      snapshot.Notes,
+		Date:           time.Now(),
+	})
+}
+
+// UndoCheckout reverses the most recent checkout of the device with the given bagTag to the student with the given otherID,
+// restoring the device's previous User, Status, and Notes and removing the checkout's verification.
+// Only the user who made the checkout can undo it, and only within UndoWindow
+func UndoCheckout(ctx context.Context, otherID, bagTag string) error {
+	commitUser := ctx.Value(UserKey).(*User)
+
+	c, err := Checkouts.GetLastCheckout(ctx, bagTag, otherID)
+	if err != nil {
+		return err
+	}
+
+	if c == nil {
+		return &Error{Description: fmt.Sprintf("No checkout of Bag Tag %s to Student(%s) found", bagTag, otherID), Err: nil, RequestError: true}
+	}
+
+	if c.Username != commitUser.Username {
+		return &Error{Description: fmt.Sprintf("Checkout of Bag Tag %s can only be undone by %s", bagTag, c.Username), Err: nil, RequestError: true}
+	}
+
+	if time.Since(c.Date) > UndoWindow {
+		return &Error{Description: fmt.Sprintf("Checkout of Bag Tag %s is older than %v and can't be undone", bagTag, UndoWindow), Err: nil, RequestError: true}
+	}
+
+	snapshot, err := Devices.GetDevice(ctx, bagTag)
+	if err != nil {
+		return err
+	}
+
+	ok, err := Devices.RestoreDevice(ctx, c.DeviceID, c.Name, c.PrevUser, c.PrevStatus, c.PrevNotes)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return &Error{Description: fmt.Sprintf("Device with Bag Tag %s is no longer checked out to %s", bagTag, c.Name), Err: nil, RequestError: true}
+	}
+
+	if err = Devices.DeleteVerification(ctx, c.VerificationID); err != nil {
+		return err
+	}
+
+	if err = Checkouts.UndoCheckout(ctx, c.ID, time.Now()); err != nil {
+		return err
+	}
+
+	return auditDevice(ctx, AuditActionUndoCheckout, otherID, bagTag, snapshot)
+}
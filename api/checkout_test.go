@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// checkoutForUndo checks out a device with the given bagTag in Storage to Jane Doe (100001), returning the device before the checkout
+func checkoutForUndo(t *testing.T, ctx context.Context, bagTag string) *Device {
+	t.Helper()
+
+	exec(t, ctx, "INSERT INTO devices(Bag_Tag, Status, User, Notes) VALUES (?, 'Storage', '', 'Old note');", bagTag)
+
+	before, err := Devices.GetDevice(ctx, bagTag)
+	if err != nil {
+		t.Fatal("GetDevice returned an error:", err)
+	}
+
+	if _, err = CheckoutDevice(ctx, "100001", bagTag, ""); err != nil {
+		t.Fatal("CheckoutDevice returned an error:", err)
+	}
+
+	return before
+}
+
+func TestUndoCheckout(t *testing.T) {
+	ctx := oneRosterContext(t)
+	before := checkoutForUndo(t, ctx, "1001")
+
+	//the user was edited after the checkout
+	exec(t, ctx, "UPDATE devices SET User = 'JANE DOE ' WHERE Bag_Tag = '1001';")
+
+	if err := UndoCheckout(ctx, "100001", "1001"); err != nil {
+		t.Fatal("UndoCheckout returned an error:", err)
+	}
+
+	d, err := Devices.GetDevice(ctx, "1001")
+	if err != nil {
+		t.Fatal("GetDevice returned an error:", err)
+	}
+	if d.Status != before.Status || d.User != before.User || d.Notes != before.Notes {
+		t.Errorf("Device after undo = %+v, want %+v", d, before)
+	}
+
+	verifications, err := Devices.GetVerifications(ctx, d.ID)
+	if err != nil {
+		t.Fatal("GetVerifications returned an error:", err)
+	}
+	if len(verifications) != 0 {
+		t.Errorf("GetVerifications = %+v, want the checkout's verification removed", verifications)
+	}
+
+	if err = UndoCheckout(ctx, "100001", "1001"); err == nil || !strings.Contains(err.Error(), "No checkout") {
+		t.Errorf("second UndoCheckout = %v, want no checkout error", err)
+	}
+}
+
+func TestUndoCheckoutDenied(t *testing.T) {
+	ctx := oneRosterContext(t)
+	checkoutForUndo(t, ctx, "1001")
+
+	other := context.WithValue(ctx, UserKey, &User{Username: "other", DisplayName: "Other Tech", Roles: []Role{RoleCheckout}})
+	if err := UndoCheckout(other, "100001", "1001"); err == nil || !strings.Contains(err.Error(), "can only be undone by tech") {
+		t.Errorf("UndoCheckout by another user = %v, want error", err)
+	}
+
+	exec(t, ctx, "UPDATE checkouts SET date = ?;", time.Now().Add(-UndoWindow-time.Minute))
+	if err := UndoCheckout(ctx, "100001", "1001"); err == nil || !strings.Contains(err.Error(), "can't be undone") {
+		t.Errorf("UndoCheckout after UndoWindow = %v, want error", err)
+	}
+
+	d, err := Devices.GetDevice(ctx, "1001")
+	if err != nil {
+		t.Fatal("GetDevice returned an error:", err)
+	}
+	if d.Status != "Checked Out" || d.User != "Jane Doe" {
+		t.Errorf("Device after denied undo = %+v, want Checked Out to Jane Doe", d)
+	}
+}
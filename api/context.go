@@ -10,3 +10,6 @@ const SkywardTransactionKey contextKey = 1
 
 // UserKey is the context key for the user for a request
 const UserKey contextKey = 2
+
+// RequestIDKey is the context key for the ID of a request
+const RequestIDKey contextKey = 3
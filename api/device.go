@@ -2,87 +2,253 @@ package api
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"strings"
 	"time"
 )
 
+// Device represents an inventory device
+type Device struct {
+	ID              int    `json:"id"`
+	InventoryNumber string `json:"inventory_number"`
+	SerialNumber    string `json:"serial_number"`
+	BagTag          string `json:"bag_tag"`
+	Status          string `json:"status"`
+	User            string `json:"user"`
+	DeviceType      string `json:"device_type"`
+	Manufacturer    string `json:"manufacturer"`
+	Model           string `json:"model"`
+	Campus          string `json:"campus"`
+	Room            string `json:"room"`
+	Notes           string `json:"notes"`
+}
+
+// LookupDevice returns the Device with the given serialNumber, inventoryNumber, or bagTag, or nil if it doesn't exist.
+// Exactly one of serialNumber, inventoryNumber, and bagTag must be non-empty
+func LookupDevice(ctx context.Context, serialNumber, inventoryNumber, bagTag string) (*Device, error) {
+	switch {
+	case serialNumber != "" && inventoryNumber == "" && bagTag == "":
+		return Devices.GetDeviceBySerialNumber(ctx, serialNumber)
+	case inventoryNumber != "" && serialNumber == "" && bagTag == "":
+		return Devices.GetDeviceByInventoryNumber(ctx, inventoryNumber)
+	case bagTag != "" && serialNumber == "" && inventoryNumber == "":
+		return Devices.GetDevice(ctx, bagTag)
+	default:
+		return nil, &Error{Description: "Exactly one of serial number, inventory number, or bag tag must be given", Err: nil, RequestError: true}
+	}
+}
+
+// resolveBagTag returns the bag tag of the device with the given bag tag, serial number, or inventory number, checked in that order.
+// If no device matches, id is returned unchanged
+func resolveBagTag(ctx context.Context, id string) (string, error) {
+	d, err := Devices.GetDevice(ctx, id)
+	if err != nil || d != nil {
+		return id, err
+	}
+
+	if d, err = Devices.GetDeviceBySerialNumber(ctx, id); err != nil {
+		return "", err
+	}
+
+	if d == nil {
+		if d, err = Devices.GetDeviceByInventoryNumber(ctx, id); err != nil {
+			return "", err
+		}
+	}
+
+	if d == nil {
+		return id, nil
+	}
+
+	if d.BagTag == "" {
+		return "", &Error{Description: fmt.Sprintf("Device %s doesn't have a Bag Tag", id), Err: nil, RequestError: true}
+	}
+
+	return d.BagTag, nil
+}
+
+// getDevice returns a non-empty description if the device with the given bagTag can't be checked out
 func getDevice(ctx context.Context, bagTag string) (string, error) {
-	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+	d, err := Devices.GetDevice(ctx, bagTag)
+	if err != nil {
+		return "", err
+	}
 
-	var (
-		user   *string
-		status *string
-	)
-	err := tx.QueryRow("SELECT User, Status FROM devices WHERE bag_tag = ?;", bagTag).Scan(
-		&(user),
-		&(status),
-	)
+	if d == nil {
+		return fmt.Sprintf("Bag Tag %s doesn't exist", bagTag), nil
+	}
 
-	switch {
-	case err == sql.ErrNoRows:
+	if d.Status != "Storage" {
+		return fmt.Sprintf(`Bag Tag %s is not in Storage (Status is "%s")`, bagTag, d.Status), nil
+	}
+
+	if d.User != "" {
+		return fmt.Sprintf(`Bag Tag %s already has a User assigned (User is "%s")`, bagTag, d.User), nil
+	}
+
+	return "", nil
+}
+
+// getAssignedDevice returns a non-empty description if the device with the given bagTag
+// is not checked out to the user with the given name
+func getAssignedDevice(ctx context.Context, bagTag, name string) (string, error) {
+	d, err := Devices.GetDevice(ctx, bagTag)
+	if err != nil {
+		return "", err
+	}
+
+	if d == nil {
 		return fmt.Sprintf("Bag Tag %s doesn't exist", bagTag), nil
-	case err != nil:
-		return "", &Error{Description: fmt.Sprintf("Could not query Device(%s)", bagTag), Err: err}
 	}
 
-	if *status != "Storage" {
-		return fmt.Sprintf(`Bag Tag %s is not in Storage (Status is "%s")`, bagTag, *status), nil
+	if d.Status != "Checked Out" {
+		return fmt.Sprintf(`Bag Tag %s is not Checked Out (Status is "%s")`, bagTag, d.Status), nil
 	}
 
-	if *user != "" {
-		return fmt.Sprintf(`Bag Tag %s already has a User assigned (User is "%s")`, bagTag, *user), nil
+	if nameKey(d.User) != nameKey(name) {
+		return fmt.Sprintf(`Bag Tag %s is not assigned to %s (User is "%s")`, bagTag, name, d.User), nil
 	}
 
 	return "", nil
 }
 
-func getDeviceList(ctx context.Context, name string) ([]int, error) {
-	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+// formatNote returns a dated note by commitUser for the devices.Notes field.
+// extraNote, if non-empty, will be indented below the note
+func formatNote(commitUser *User, text, extraNote string) string {
+	note := fmt.Sprintf("\n%s %s: %s\n",
+		time.Now().Format("01/02/06"),
+		commitUser.DisplayName,
+		text,
+	)
 
-	rows, err := tx.Query(`SELECT id FROM devices WHERE user=?;`, name)
+	if extraNote != "" {
+		//indent extraNote
+		extraNote = strings.Replace(extraNote, "\r\n", "\n", -1)
+
+		var extraNoteLines []string
+		for _, line := range strings.Split(extraNote, "\n") {
+			extraNoteLines = append(extraNoteLines, "\t"+line)
+		}
+
+		note = fmt.Sprintf("%s%s\n", note, strings.Join(extraNoteLines, "\n"))
+	}
+
+	return note
+}
+
+// verifyDevice records a verification of the device with the given bagTag by the current user,
+// returning the id of the verification
+func verifyDevice(ctx context.Context, bagTag string) (int64, error) {
+	commitUser := ctx.Value(UserKey).(*User)
+	return Devices.VerifyDevice(ctx, bagTag, commitUser.Username, time.Now())
+}
+
+// assignDevice sets the User of the device with the given bagTag to name and the Status to Checked Out,
+// appending note to the notes field
+func assignDevice(ctx context.Context, bagTag, name, note string) error {
+	ok, err := Devices.AssignDevice(ctx, bagTag, name, note)
 	if err != nil {
-		return nil, &Error{Description: "Could not query Device list", Err: err}
+		return err
 	}
-	defer rows.Close()
 
-	var devices []int
+	if !ok {
+		return &Error{Description: fmt.Sprintf("Device with Bag Tag %s is missing or not in storage", bagTag), Err: nil, RequestError: true}
+	}
 
-	for rows.Next() {
-		var d *int
-		if err := rows.Scan(&(d)); err != nil {
-			return nil, &Error{Description: "Could not scan Device row", Err: err}
-		}
+	return nil
+}
 
-		devices = append(devices, *d)
+// unassignDevice clears the User of the device with the given bagTag, checked out to name,
+// and sets the Status to status, appending note to the notes field
+func unassignDevice(ctx context.Context, bagTag, name, status, note string) error {
+	ok, err := Devices.UnassignDevice(ctx, bagTag, name, status, note)
+	if err != nil {
+		return err
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, &Error{Description: "Could not scan Device rows", Err: err}
+	if !ok {
+		return &Error{Description: fmt.Sprintf("Device with Bag Tag %s is missing or not checked out to %s", bagTag, name), Err: nil, RequestError: true}
 	}
 
-	return devices, nil
+	return Loans.ReturnLoan(ctx, bagTag)
 }
 
 // CheckoutDevice checks out the device with the given bagTag to the student with the given otherID.
-// extraNote, if non-empty, will be appended to the notes field
-func CheckoutDevice(ctx context.Context, otherID, bagTag, extraNote string) error {
+// bagTag may also be the serial number or inventory number of the device.
+// extraNote, if non-empty, will be appended to the notes field. The id of the checkout is returned
+func CheckoutDevice(ctx context.Context, otherID, bagTag, extraNote string) (int, error) {
 	student, err := GetStudent(ctx, otherID)
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	if bagTag, err = resolveBagTag(ctx, bagTag); err != nil {
+		return 0, err
 	}
 
 	status, err := student.Status(ctx)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	if status.Type == StatusTypeNone {
-		return &Error{Description: fmt.Sprintf("Student unable to check out Chromebook: %s", status.Issues[0].Description), Err: nil, RequestError: true}
+		return 0, &Error{Description: fmt.Sprintf("Student unable to check out Chromebook: %s", status.reason()), Err: nil, RequestError: true}
 	}
 
 	deviceStatus, err := getDevice(ctx, bagTag)
+	if err != nil {
+		return 0, err
+	}
+
+	if deviceStatus != "" {
+		return 0, &Error{Description: deviceStatus, Err: nil, RequestError: true}
+	}
+
+	snapshot, err := Devices.GetDevice(ctx, bagTag)
+	if err != nil {
+		return 0, err
+	}
+
+	commitUser := ctx.Value(UserKey).(*User)
+
+	note := formatNote(commitUser, fmt.Sprintf("Checked out Bag Tag %s (%s) to %s",
+		bagTag,
+		strings.Replace(string(status.Type), "_", " ", -1),
+		student.Name(),
+	), extraNote)
+
+	if err = assignDevice(ctx, bagTag, student.Name(), note); err != nil {
+		return 0, err
+	}
+
+	verificationID, err := verifyDevice(ctx, bagTag)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := createCheckout(ctx, snapshot, verificationID, student, status.Type)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, auditDevice(ctx, AuditActionCheckout, student.OtherID, bagTag, snapshot)
+}
+
+// CheckinDevice checks in the device with the given bagTag from the student with the given otherID.
+// If damaged is true or damages is non-empty, the device will be set to Needs Repair instead of Storage.
+// If damages is non-empty, a charge will be created for the student with the given damages.
+// extraNote, if non-empty, will be appended to the notes field
+func CheckinDevice(ctx context.Context, otherID, bagTag string, damaged bool, damages []*Damage, extraNote string) error {
+	if damageStatus := checkDamages(damages); damageStatus != "" {
+		return &Error{Description: damageStatus, Err: nil, RequestError: true}
+	}
+
+	student, err := GetStudent(ctx, otherID)
+	if err != nil {
+		return err
+	}
+
+	deviceStatus, err := getAssignedDevice(ctx, bagTag, student.Name())
 	if err != nil {
 		return err
 	}
@@ -91,49 +257,136 @@ func CheckoutDevice(ctx context.Context, otherID, bagTag, extraNote string) erro
 		return &Error{Description: deviceStatus, Err: nil, RequestError: true}
 	}
 
-	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+	snapshot, err := Devices.GetDevice(ctx, bagTag)
+	if err != nil {
+		return err
+	}
+
 	commitUser := ctx.Value(UserKey).(*User)
 
-	note := fmt.Sprintf("\n%s %s: Checked out Bag Tag %s (%s) to %s\n",
-		time.Now().Format("01/02/06"),
-		commitUser.DisplayName,
+	newStatus := "Storage"
+	if damaged || len(damages) > 0 {
+		newStatus = "Needs Repair"
+	}
+
+	note := formatNote(commitUser, fmt.Sprintf("Checked in Bag Tag %s (%s) from %s",
 		bagTag,
-		strings.Replace(string(status.Type), "_", " ", -1),
+		newStatus,
 		student.Name(),
-	)
+	), extraNote)
 
-	if extraNote != "" {
-		//indent extraNote
-		extraNote = strings.Replace(extraNote, "\r\n", "\n", -1)
+	if err = unassignDevice(ctx, bagTag, student.Name(), newStatus, note); err != nil {
+		return err
+	}
 
-		var extraNoteLines []string
-		for _, line := range strings.Split(extraNote, "\n") {
-			extraNoteLines = append(extraNoteLines, "\t"+line)
+	if len(damages) > 0 {
+		chargeNote := strings.TrimSpace(formatNote(commitUser, fmt.Sprintf("Damage assessed on check in of Bag Tag %s", bagTag), extraNote))
+		if err = createCharge(ctx, student.OtherID, bagTag, student.Name(), damages, chargeNote); err != nil {
+			return err
 		}
+	}
 
-		note = fmt.Sprintf("%s%s\n", note, strings.Join(extraNoteLines, "\n"))
+	if _, err = verifyDevice(ctx, bagTag); err != nil {
+		return err
+	}
+
+	return auditDevice(ctx, AuditActionCheckin, student.OtherID, bagTag, snapshot)
+}
+
+// SwapDevice returns the device with the given oldBagTag from the student with the given otherID for repair
+// and checks out the device with the given newBagTag to the student in its place.
+// reason, if non-empty, will be appended to the notes field of both devices
+func SwapDevice(ctx context.Context, otherID, oldBagTag, newBagTag, reason string) error {
+	if oldBagTag == newBagTag {
+		return &Error{Description: fmt.Sprintf("Can't swap Bag Tag %s with itself", oldBagTag), Err: nil, RequestError: true}
 	}
 
-	res, err := tx.Exec(`
-	UPDATE devices SET User = ?, Status = "Checked Out", Notes = CONCAT(Notes, ?)
-	WHERE bag_tag = ? AND Status = "Storage";
-	`, student.Name(), note, bagTag)
+	student, err := GetStudent(ctx, otherID)
+	if err != nil {
+		return err
+	}
 
+	deviceStatus, err := getAssignedDevice(ctx, oldBagTag, student.Name())
 	if err != nil {
-		return &Error{Description: fmt.Sprintf("Could not update Device(%s)", bagTag), Err: err}
+		return err
 	}
 
-	if n, _ := res.RowsAffected(); n != 1 {
-		return &Error{Description: fmt.Sprintf("Device with Bag Tag %s is missing or not in storage", bagTag), Err: nil, RequestError: true}
+	if deviceStatus != "" {
+		return &Error{Description: deviceStatus, Err: nil, RequestError: true}
+	}
+
+	deviceStatus, err = getDevice(ctx, newBagTag)
+	if err != nil {
+		return err
+	}
+
+	if deviceStatus != "" {
+		return &Error{Description: deviceStatus, Err: nil, RequestError: true}
 	}
 
-	_, err = tx.Exec(`
-	INSERT INTO verifications(device_id, username, date) 
-	SELECT id, ?, NOW() FROM devices WHERE bag_tag = ?;`,
-		commitUser.Username, bagTag)
+	oldSnapshot, err := Devices.GetDevice(ctx, oldBagTag)
 	if err != nil {
-		return &Error{Description: fmt.Sprintf("Could not verify Device(%s)", bagTag), Err: err}
+		return err
 	}
 
-	return nil
+	newSnapshot, err := Devices.GetDevice(ctx, newBagTag)
+	if err != nil {
+		return err
+	}
+
+	//status is checked without the old device so it doesn't count against the student,
+	//and before any changes so an ineligible student's old device isn't returned
+	devices, charges, err := student.statusData(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	var otherDevices []int
+	for _, id := range devices {
+		if id != oldSnapshot.ID {
+			otherDevices = append(otherDevices, id)
+		}
+	}
+
+	status := Rules.Evaluate(student, otherDevices, charges)
+	if status.Type == StatusTypeNone {
+		return &Error{Description: fmt.Sprintf("Student unable to swap Chromebook: %s", status.reason()), Err: nil, RequestError: true}
+	}
+
+	commitUser := ctx.Value(UserKey).(*User)
+
+	note := formatNote(commitUser, fmt.Sprintf("Checked in Bag Tag %s (Needs Repair) from %s, swapped for Bag Tag %s",
+		oldBagTag,
+		student.Name(),
+		newBagTag,
+	), reason)
+
+	if err = unassignDevice(ctx, oldBagTag, student.Name(), "Needs Repair", note); err != nil {
+		return err
+	}
+
+	note = formatNote(commitUser, fmt.Sprintf("Checked out Bag Tag %s (%s) to %s, swapped for Bag Tag %s",
+		newBagTag,
+		strings.Replace(string(status.Type), "_", " ", -1),
+		student.Name(),
+		oldBagTag,
+	), reason)
+
+	if err = assignDevice(ctx, newBagTag, student.Name(), note); err != nil {
+		return err
+	}
+
+	if _, err = verifyDevice(ctx, oldBagTag); err != nil {
+		return err
+	}
+
+	if _, err = verifyDevice(ctx, newBagTag); err != nil {
+		return err
+	}
+
+	if err = auditDevice(ctx, AuditActionSwapCheckin, student.OtherID, oldBagTag, oldSnapshot); err != nil {
+		return err
+	}
+
+	return auditDevice(ctx, AuditActionSwapCheckout, student.OtherID, newBagTag, newSnapshot)
 }
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckinDevice(t *testing.T) {
+	ctx := oneRosterContext(t)
+
+	//the stored name differs in case and trailing space from the student's name
+	exec(t, ctx, "INSERT INTO devices(Bag_Tag, Status, User, Notes) VALUES ('1001', 'Checked Out', 'JANE DOE ', 'Old note');")
+	exec(t, ctx, "INSERT INTO devices(Bag_Tag, Status, User) VALUES ('1002', 'Checked Out', 'John Roe');")
+	exec(t, ctx, "INSERT INTO devices(Bag_Tag, Status, User) VALUES ('1003', 'Storage', '');")
+
+	for bagTag, want := range map[string]string{
+		"1002": "is not assigned to Jane Doe",
+		"1003": "is not Checked Out",
+		"9999": "doesn't exist",
+	} {
+		err := CheckinDevice(ctx, "100001", bagTag, false, nil, "")
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("CheckinDevice(%s) = %v, want error containing %q", bagTag, err, want)
+		}
+	}
+
+	if err := CheckinDevice(ctx, "100001", "1001", false, nil, "Returned at end of year"); err != nil {
+		t.Fatal("CheckinDevice returned an error:", err)
+	}
+
+	d, err := Devices.GetDevice(ctx, "1001")
+	if err != nil {
+		t.Fatal("GetDevice returned an error:", err)
+	}
+	if d.Status != "Storage" || d.User != "" {
+		t.Errorf("Device after checkin = %+v, want Storage with no User", d)
+	}
+	if !strings.HasPrefix(d.Notes, "Old note\n") || !strings.Contains(d.Notes, "Tech Person: Checked in Bag Tag 1001 (Storage) from Jane Doe\n\tReturned at end of year") {
+		t.Errorf("Notes = %q, want the checkin note appended", d.Notes)
+	}
+
+	verifications, err := Devices.GetVerifications(ctx, d.ID)
+	if err != nil {
+		t.Fatal("GetVerifications returned an error:", err)
+	}
+	if len(verifications) != 1 || verifications[0].Username != "tech" {
+		t.Errorf("GetVerifications = %+v, want one verification by tech", verifications)
+	}
+
+	if err = CheckinDevice(ctx, "100001", "1001", false, nil, ""); err == nil {
+		t.Error("CheckinDevice of a checked in device didn't return an error")
+	}
+}
+
+func TestSwapDevice(t *testing.T) {
+	ctx := oneRosterContext(t)
+	exec(t, ctx, "INSERT INTO devices(Bag_Tag, Status, User) VALUES ('1001', 'Checked Out', 'Jane Doe');")
+	exec(t, ctx, "INSERT INTO devices(Bag_Tag, Status, User) VALUES ('1002', 'Storage', '');")
+
+	if err := SwapDevice(ctx, "100001", "1001", "1002", "Cracked screen"); err != nil {
+		t.Fatal("SwapDevice returned an error:", err)
+	}
+
+	for bagTag, want := range map[string][2]string{"1001": {"Needs Repair", ""}, "1002": {"Checked Out", "Jane Doe"}} {
+		d, err := Devices.GetDevice(ctx, bagTag)
+		if err != nil {
+			t.Fatal("GetDevice returned an error:", err)
+		}
+		if d.Status != want[0] || d.User != want[1] {
+			t.Errorf("Device %s after swap = %+v, want %s to %q", bagTag, d, want[0], want[1])
+		}
+		if !strings.Contains(d.Notes, "\tCracked screen") {
+			t.Errorf("Device %s notes = %q, want the reason", bagTag, d.Notes)
+		}
+	}
+}
+
+func TestSwapDeviceIneligible(t *testing.T) {
+	ctx := oneRosterContext(t)
+	exec(t, ctx, "INSERT INTO devices(Bag_Tag, Status, User) VALUES ('1001', 'Checked Out', 'Jane Doe');")
+	exec(t, ctx, "INSERT INTO devices(Bag_Tag, Status, User) VALUES ('1002', 'Storage', '');")
+	exec(t, ctx, "INSERT INTO charges(User, Amount_Paid, Charges) VALUES ('Jane Doe', 0, 'Screen: 50.00');")
+
+	err := SwapDevice(ctx, "100001", "1001", "1002", "")
+	if err == nil || !strings.Contains(err.Error(), "unable to swap") || strings.Contains(err.Error(), "device checked out") {
+		t.Fatalf("SwapDevice = %v, want an error for the charge only", err)
+	}
+
+	//nothing was changed
+	for bagTag, want := range map[string][2]string{"1001": {"Checked Out", "Jane Doe"}, "1002": {"Storage", ""}} {
+		d, err := Devices.GetDevice(ctx, bagTag)
+		if err != nil {
+			t.Fatal("GetDevice returned an error:", err)
+		}
+		if d.Status != want[0] || d.User != want[1] || d.Notes != "" {
+			t.Errorf("Device %s after failed swap = %+v, want unchanged", bagTag, d)
+		}
+	}
+}
+
+func TestLookupDevice(t *testing.T) {
+	ctx := oneRosterContext(t)
+	exec(t, ctx, "INSERT INTO devices(Inventory_Number, Serial_Number, Bag_Tag, Status, User) VALUES ('INV1', 'SER1', '1001', 'Storage', '');")
+	exec(t, ctx, "INSERT INTO devices(Inventory_Number, Serial_Number, Bag_Tag, Status, User) VALUES ('INV2', 'DUP', '1002', 'Storage', '');")
+	exec(t, ctx, "INSERT INTO devices(Inventory_Number, Serial_Number, Bag_Tag, Status, User) VALUES ('INV3', 'DUP', '1003', 'Storage', '');")
+	exec(t, ctx, "INSERT INTO devices(Inventory_Number, Serial_Number, Status, User) VALUES ('INV4', 'SER4', 'Storage', '');")
+
+	for _, test := range []struct {
+		serial, inventory, bagTag string
+		want                      string
+	}{
+		{"SER1", "", "", "1001"},
+		{"", "INV2", "", "1002"},
+		{"", "", "1003", "1003"},
+		{"SER9", "", "", ""},
+		{"", "INV9", "", ""},
+	} {
+		d, err := LookupDevice(ctx, test.serial, test.inventory, test.bagTag)
+		if err != nil {
+			t.Fatalf("LookupDevice(%q, %q, %q) returned an error: %v", test.serial, test.inventory, test.bagTag, err)
+		}
+		if (d == nil && test.want != "") || (d != nil && d.BagTag != test.want) {
+			t.Errorf("LookupDevice(%q, %q, %q) = %+v, want bag tag %q", test.serial, test.inventory, test.bagTag, d, test.want)
+		}
+	}
+
+	for _, args := range [][3]string{{"", "", ""}, {"SER1", "INV1", ""}, {"DUP", "", ""}} {
+		if _, err := LookupDevice(ctx, args[0], args[1], args[2]); err == nil || !err.(*Error).RequestError {
+			t.Errorf("LookupDevice(%q, %q, %q) = %v, want a request error", args[0], args[1], args[2], err)
+		}
+	}
+
+	for id, want := range map[string]string{
+		"1001": "1001",
+		"SER1": "1001",
+		"INV2": "1002",
+		"9999": "9999",
+	} {
+		if bagTag, err := resolveBagTag(ctx, id); err != nil || bagTag != want {
+			t.Errorf("resolveBagTag(%s) = %q, %v, want %q", id, bagTag, err, want)
+		}
+	}
+
+	for id, want := range map[string]string{
+		"DUP":  "matches more than one device",
+		"INV4": "doesn't have a Bag Tag",
+	} {
+		if _, err := resolveBagTag(ctx, id); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("resolveBagTag(%s) = %v, want error containing %q", id, err, want)
+		}
+	}
+
+	//devices can be checked out by serial number
+	if _, err := CheckoutDevice(ctx, "100001", "SER1", ""); err != nil {
+		t.Fatal("CheckoutDevice by serial number returned an error:", err)
+	}
+	if d, _ := Devices.GetDevice(ctx, "1001"); d.User != "Jane Doe" {
+		t.Errorf("Device after checkout by serial number = %+v, want checked out to Jane Doe", d)
+	}
+}
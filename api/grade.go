@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Grade is a student grade level
+type Grade int
+
+// Grades that aren't numbered
+const (
+	GradePreKindergarten Grade = -1
+	GradeKindergarten    Grade = 0
+)
+
+// ParseGrade parses a grade level like "PK", "K", "KG", or "5"
+func ParseGrade(s string) (Grade, error) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "PK":
+		return GradePreKindergarten, nil
+	case "K", "KG":
+		return GradeKindergarten, nil
+	}
+
+	g, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || g < int(GradePreKindergarten) || g > 12 {
+		return 0, fmt.Errorf("Invalid grade: %s", s)
+	}
+	return Grade(g), nil
+}
+
+func (g Grade) String() string {
+	switch g {
+	case GradePreKindergarten:
+		return "PK"
+	case GradeKindergarten:
+		return "K"
+	}
+	return strconv.Itoa(int(g))
+}
+
+// MarshalJSON marshals the Grade as a string
+func (g Grade) MarshalJSON() ([]byte, error) {
+	return json.Marshal(g.String())
+}
+
+// UnmarshalJSON unmarshals the Grade from a string or number
+func (g *Grade) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		var i int
+		if err := json.Unmarshal(b, &i); err != nil {
+			return fmt.Errorf("Invalid grade: %s", string(b))
+		}
+		s = strconv.Itoa(i)
+	}
+
+	grade, err := ParseGrade(s)
+	if err != nil {
+		return err
+	}
+	*g = grade
+	return nil
+}
@@ -0,0 +1,240 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Verification represents a record of a user handling a device
+type Verification struct {
+	ID       int64
+	DeviceID int
+	Username string
+	Date     time.Time
+}
+
+// History entry sources
+const (
+	HistorySourceNote         = "note"
+	HistorySourceVerification = "verification"
+	HistorySourceAudit        = "audit"
+)
+
+// History entry actions parsed from notes. Audit entries use the audit event's action
+const (
+	HistoryActionCheckout     = "checkout"
+	HistoryActionCheckin      = "checkin"
+	HistoryActionLoan         = "loan"
+	HistoryActionNote         = "note"
+	HistoryActionVerification = "verification"
+)
+
+// HistoryEntry represents a single event in a device's history.
+// Entries parsed from notes only have a date, not a time, and have DateOnly set. Notes written without a date have a nil Date
+type HistoryEntry struct {
+	Date     *time.Time  `json:"date"`
+	DateOnly bool        `json:"date_only,omitempty"`
+	Source   string      `json:"source"`
+	Action   string      `json:"action"`
+	User     string      `json:"user,omitempty"`
+	Student  string      `json:"student,omitempty"`
+	OtherID  string      `json:"other_id,omitempty"`
+	Text     string      `json:"text,omitempty"`
+	Note     string      `json:"note,omitempty"`
+	Before   interface{} `json:"before,omitempty"`
+	After    interface{} `json:"after,omitempty"`
+}
+
+// noteRegexp matches the first line of a note written by formatNote
+var noteRegexp = regexp.MustCompile(`^(\d{2}/\d{2}/\d{2}) ([^:]+): (.*)$`)
+
+// noteActionRegexps match the text of notes written by device actions, capturing the student's name
+var noteActionRegexps = []struct {
+	action string
+	re     *regexp.Regexp
+}{
+	{HistoryActionCheckout, regexp.MustCompile(`^Checked out Bag Tag \S+ \([^)]*\) to (.+?)(?:, swapped for Bag Tag \S+)?$`)},
+	{HistoryActionCheckin, regexp.MustCompile(`^Checked in Bag Tag \S+ \([^)]*\) from (.+?)(?:, swapped for Bag Tag \S+)?$`)},
+	{HistoryActionLoan, regexp.MustCompile(`^Loaned Bag Tag \S+ \([^)]*\) to (.+?), due \S+$`)},
+}
+
+// parseNotes returns the HistoryEntries in a device's notes field. Lines indented with a tab are added to the previous entry's Note.
+// Other lines that don't match the format written by formatNote are added to the previous entry's Note, or to an undated entry
+// if there is no previous entry
+func parseNotes(notes string) []*HistoryEntry {
+	var (
+		entries []*HistoryEntry
+		last    *HistoryEntry
+	)
+
+	addNote := func(line string) {
+		if last == nil {
+			last = &HistoryEntry{Source: HistorySourceNote, Action: HistoryActionNote}
+			entries = append(entries, last)
+		}
+		if last.Date == nil {
+			last.Text = strings.TrimPrefix(last.Text+"\n"+line, "\n")
+			return
+		}
+		last.Note = strings.TrimPrefix(last.Note+"\n"+line, "\n")
+	}
+
+	for _, line := range strings.Split(strings.Replace(notes, "\r\n", "\n", -1), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "\t") {
+			addNote(strings.TrimPrefix(line, "\t"))
+			continue
+		}
+
+		m := noteRegexp.FindStringSubmatch(line)
+		if m == nil {
+			addNote(line)
+			continue
+		}
+
+		date, err := time.ParseInLocation("01/02/06", m[1], time.Local)
+		if err != nil {
+			addNote(line)
+			continue
+		}
+
+		last = &HistoryEntry{Date: &date, DateOnly: true, Source: HistorySourceNote, Action: HistoryActionNote, User: m[2], Text: m[3]}
+		for _, a := range noteActionRegexps {
+			if n := a.re.FindStringSubmatch(m[3]); n != nil {
+				last.Action = a.action
+				last.Student = n[1]
+				break
+			}
+		}
+
+		entries = append(entries, last)
+	}
+
+	return entries
+}
+
+// auditNoteActions are the note actions written by the device actions that are also audited
+var auditNoteActions = map[string]string{
+	AuditActionCheckout:     HistoryActionCheckout,
+	AuditActionSwapCheckout: HistoryActionCheckout,
+	AuditActionCheckin:      HistoryActionCheckin,
+	AuditActionSwapCheckin:  HistoryActionCheckin,
+	AuditActionLoan:         HistoryActionLoan,
+}
+
+// verificationWindow is how close a verification must be to an audit event by the same user to have been recorded by it
+const verificationWindow = time.Minute
+
+// sortDate returns the date used to sort e. Date only entries are sorted after timed entries on the same day
+func sortDate(e *HistoryEntry) time.Time {
+	if e.DateOnly {
+		return e.Date.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return *(e.Date)
+}
+
+// GetDeviceHistory returns a chronological history of the device with the given bagTag,
+// merged from its notes, verifications, and audit events.
+// Notes and verifications written by an audited action are merged into the action's audit entry
+func GetDeviceHistory(ctx context.Context, bagTag string) ([]*HistoryEntry, error) {
+	d, err := Devices.GetDevice(ctx, bagTag)
+	if err != nil {
+		return nil, err
+	}
+
+	if d == nil {
+		return nil, &Error{Description: fmt.Sprintf("Bag Tag %s doesn't exist", bagTag), Err: nil, RequestError: true}
+	}
+
+	notes := parseNotes(d.Notes)
+
+	verifications, err := Devices.GetVerifications(ctx, d.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := GetAuditEvents(ctx, &AuditFilter{DeviceID: d.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	//audit events are returned newest first
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Date.Before(events[j].Date) })
+
+	var (
+		history             []*HistoryEntry
+		mergedNotes         = make(map[*HistoryEntry]bool)
+		mergedVerifications = make(map[*Verification]bool)
+	)
+
+	for _, e := range events {
+		date := e.Date
+		entry := &HistoryEntry{
+			Date:    &date,
+			Source:  HistorySourceAudit,
+			Action:  e.Action,
+			User:    e.DisplayName,
+			OtherID: e.OtherID,
+			Before:  e.Before,
+			After:   e.After,
+		}
+
+		//merge the first unmerged note written by the action on the same day
+		if action, ok := auditNoteActions[e.Action]; ok {
+			y, m, day := date.In(time.Local).Date()
+			for _, n := range notes {
+				if mergedNotes[n] || n.Action != action || n.Date == nil {
+					continue
+				}
+				if ny, nm, nd := n.Date.Date(); ny == y && nm == m && nd == day {
+					mergedNotes[n] = true
+					entry.Student, entry.Text, entry.Note = n.Student, n.Text, n.Note
+					break
+				}
+			}
+		}
+
+		//merge the verification recorded by the action
+		for _, v := range verifications {
+			if mergedVerifications[v] || !strings.EqualFold(v.Username, e.Username) {
+				continue
+			}
+			if diff := v.Date.Sub(date); diff > -verificationWindow && diff < verificationWindow {
+				mergedVerifications[v] = true
+				break
+			}
+		}
+
+		history = append(history, entry)
+	}
+
+	for _, n := range notes {
+		if !mergedNotes[n] {
+			history = append(history, n)
+		}
+	}
+
+	for _, v := range verifications {
+		if !mergedVerifications[v] {
+			date := v.Date
+			history = append(history, &HistoryEntry{Date: &date, Source: HistorySourceVerification, Action: HistoryActionVerification, User: v.Username})
+		}
+	}
+
+	//undated entries are first, and entries with the same date keep their order
+	sort.SliceStable(history, func(i, j int) bool {
+		if history[i].Date == nil || history[j].Date == nil {
+			return history[i].Date == nil && history[j].Date != nil
+		}
+		return sortDate(history[i]).Before(sortDate(history[j]))
+	})
+
+	return history, nil
+}
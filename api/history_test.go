@@ -0,0 +1,55 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetDeviceHistoryMerged(t *testing.T) {
+	ctx := testContext(t)
+
+	day := time.Date(2026, 10, 14, 0, 0, 0, 0, time.Local)
+	checkout, checkin := day.Add(9*time.Hour), day.Add(15*time.Hour)
+	notes := "Old note\n" +
+		"10/14/26 Tech Person: Checked out Bag Tag 1001 (Chromebook) to Jane Doe\n" +
+		"\tCharger included\n" +
+		"10/14/26 Tech Person: Screen is scratched\n" +
+		"10/14/26 Tech Person: Checked in Bag Tag 1001 (Chromebook) from Jane Doe\n"
+
+	exec(t, ctx, "INSERT INTO devices(id, Bag_Tag, Status, Notes) VALUES (1, '1001', 'Storage', ?);", notes)
+	exec(t, ctx, "INSERT INTO verifications(device_id, username, date) VALUES (1, 'tech', ?), (1, 'tech', ?), (1, 'other', ?);",
+		checkout.Add(time.Second), checkin, day.Add(12*time.Hour))
+	exec(t, ctx, `INSERT INTO audit_events(date, username, display_name, action, other_id, device_id, bag_tag)
+	VALUES (?, 'tech', 'Tech Person', ?, '123', 1, '1001'), (?, 'tech', 'Tech Person', ?, '123', 1, '1001');`,
+		checkout, AuditActionCheckout, checkin, AuditActionCheckin)
+
+	history, err := GetDeviceHistory(ctx, "1001")
+	if err != nil {
+		t.Fatal("GetDeviceHistory returned an error:", err)
+	}
+
+	want := []struct {
+		source, action, user, student, note string
+		dateOnly                            bool
+	}{
+		{HistorySourceNote, HistoryActionNote, "", "", "", false},
+		{HistorySourceAudit, AuditActionCheckout, "Tech Person", "Jane Doe", "Charger included", false},
+		{HistorySourceVerification, HistoryActionVerification, "other", "", "", false},
+		{HistorySourceAudit, AuditActionCheckin, "Tech Person", "Jane Doe", "", false},
+		{HistorySourceNote, HistoryActionNote, "Tech Person", "", "", true},
+	}
+
+	if len(history) != len(want) {
+		for _, e := range history {
+			t.Logf("%+v", e)
+		}
+		t.Fatalf("GetDeviceHistory returned %d entries, want %d", len(history), len(want))
+	}
+
+	for i, w := range want {
+		e := history[i]
+		if e.Source != w.source || e.Action != w.action || e.User != w.user || e.Student != w.student || e.Note != w.note || e.DateOnly != w.dateOnly {
+			t.Errorf("entry %d = %+v, want %+v", i, e, w)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"context"
+	"testing"
+)
+
+// oneRosterContext returns a testContext using a OneRoster export with the students Jane Doe (100001) and John Roe (100002)
+func oneRosterContext(t *testing.T) context.Context {
+	t.Helper()
+
+	source := writeOneRoster(t, map[string]string{
+		"users.csv": "sourcedId,status,enabledUser,orgSourcedIds,role,username,givenName,familyName,identifier,grades\n" +
+			"u1,active,true,hs,student,jdoe,Jane,Doe,100001,09\n" +
+			"u2,active,true,hs,student,jroe,John,Roe,100002,10\n",
+		"demographics.csv": "sourcedId,metadata.t2e2,metadata.economicallyDisadvantaged\n" +
+			"u1,Yes,false\n" +
+			"u2,Yes,false\n",
+	})
+
+	useRoster(t, source, nil)
+
+	return testContext(t)
+}
+
+// checkStatus checks that the Status of the student with the given otherID is want, and agrees with GetStatuses
+func checkStatus(t *testing.T, ctx context.Context, otherID string, want StatusType) {
+	t.Helper()
+
+	s, err := GetStudent(ctx, otherID)
+	if err != nil {
+		t.Fatal("GetStudent returned an error:", err)
+	}
+
+	status, err := s.Status(ctx)
+	if err != nil {
+		t.Fatal("Status returned an error:", err)
+	}
+	if status.Type != want {
+		t.Errorf("Status = %+v, want %s", status, want)
+	}
+
+	statuses, err := GetStatuses(ctx, []*Student{s})
+	if err != nil {
+		t.Fatal("GetStatuses returned an error:", err)
+	}
+	if statuses[otherID].Type != status.Type {
+		t.Errorf("GetStatuses = %+v, Status = %+v", statuses[otherID], status)
+	}
+}
+
+func TestCheckoutCheckinPayment(t *testing.T) {
+	ctx := oneRosterContext(t)
+	exec(t, ctx, "INSERT INTO devices(Inventory_Number, Serial_Number, Bag_Tag, Status, User, Model) VALUES ('INV1', 'SER1', '1001', 'Storage', '', 'Chromebook');")
+
+	checkStatus(t, ctx, "100001", StatusTypeBlackBag)
+
+	id, err := CheckoutDevice(ctx, "100001", "1001", "")
+	if err != nil {
+		t.Fatal("CheckoutDevice returned an error:", err)
+	}
+
+	receipt, err := GetCheckoutReceipt(ctx, id)
+	if err != nil {
+		t.Fatal("GetCheckoutReceipt returned an error:", err)
+	}
+	if receipt == nil || receipt.Name != "Jane Doe" || receipt.BagTag != "1001" || receipt.StatusType != StatusTypeBlackBag {
+		t.Errorf("GetCheckoutReceipt = %+v, want a black bag checkout of 1001 to Jane Doe", receipt)
+	}
+
+	checkStatus(t, ctx, "100001", StatusTypeNone)
+
+	if err = CheckinDevice(ctx, "100001", "1001", true, []*Damage{{Description: "Screen", Amount: 90}}, ""); err != nil {
+		t.Fatal("CheckinDevice returned an error:", err)
+	}
+
+	d, err := Devices.GetDevice(ctx, "1001")
+	if err != nil {
+		t.Fatal("GetDevice returned an error:", err)
+	}
+	if d.Status != "Needs Repair" || d.User != "" {
+		t.Errorf("Device after checkin = %+v, want Needs Repair with no User", d)
+	}
+
+	charges, err := Charges.GetCharges(ctx, "Jane Doe")
+	if err != nil {
+		t.Fatal("GetCharges returned an error:", err)
+	}
+	if len(charges) != 1 || charges[0].AmountCharged() != 90 {
+		t.Fatalf("GetCharges = %+v, want one charge of 90.00", charges)
+	}
+
+	//less than half of the charge is paid
+	checkStatus(t, ctx, "100001", StatusTypeNone)
+
+	for _, p := range []struct {
+		amount float32
+		want   StatusType
+	}{
+		{45, StatusTypeRedBag},
+		{45, StatusTypeBlackBag},
+	} {
+		payment, status, err := RecordPayment(ctx, charges[0].ID, "100001", p.amount, PaymentMethodCash, "")
+		if err != nil {
+			t.Fatal("RecordPayment returned an error:", err)
+		}
+		if status.Type != p.want || payment.StatusType != p.want {
+			t.Errorf("RecordPayment status = %+v, payment status = %s, want %s", status, payment.StatusType, p.want)
+		}
+		checkStatus(t, ctx, "100001", p.want)
+
+		receipt, err := GetPaymentReceipt(ctx, payment.ID)
+		if err != nil {
+			t.Fatal("GetPaymentReceipt returned an error:", err)
+		}
+		if receipt == nil || receipt.Amount != p.amount || receipt.Charged != 90 || receipt.BagTag != "1001" {
+			t.Errorf("GetPaymentReceipt = %+v, want a payment of %.2f against 90.00 for 1001", receipt, p.amount)
+		}
+	}
+
+	if _, _, err = RecordPayment(ctx, charges[0].ID, "100001", 1, PaymentMethodCash, ""); err == nil {
+		t.Error("RecordPayment of a paid charge didn't return an error")
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Loan represents a short-term loaner device checkout
+type Loan struct {
+	ID         int        `json:"id"`
+	DeviceID   int        `json:"device_id"`
+	BagTag     string     `json:"bag_tag"`
+	OtherID    string     `json:"other_id"`
+	Name       string     `json:"name"`
+	Username   string     `json:"username"`
+	CheckedOut time.Time  `json:"checked_out"`
+	Due        time.Time  `json:"due"`
+	Returned   *time.Time `json:"returned,omitempty"`
+}
+
+// Overdue returns true if the Loan hasn't been returned and is past its due date
+func (l *Loan) Overdue() bool {
+	return l.Returned == nil && l.Due.Before(time.Now())
+}
+
+// CheckoutLoaner checks out the device with the given bagTag to the student with the given otherID
+// as a loaner until due. The student's primary device doesn't prevent a loaner checkout.
+// extraNote, if non-empty, will be appended to the notes field
+func CheckoutLoaner(ctx context.Context, otherID, bagTag string, due time.Time, extraNote string) error {
+	if !due.After(time.Now()) {
+		return &Error{Description: fmt.Sprintf("Due date %s is in the past", due.Format("01/02/06")), Err: nil, RequestError: true}
+	}
+
+	student, err := GetStudent(ctx, otherID)
+	if err != nil {
+		return err
+	}
+
+	status, err := student.status(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	if status.Type == StatusTypeNone {
+		return &Error{Description: fmt.Sprintf("Student unable to check out loaner Chromebook: %s", status.reason()), Err: nil, RequestError: true}
+	}
+
+	deviceStatus, err := getDevice(ctx, bagTag)
+	if err != nil {
+		return err
+	}
+
+	if deviceStatus != "" {
+		return &Error{Description: deviceStatus, Err: nil, RequestError: true}
+	}
+
+	snapshot, err := Devices.GetDevice(ctx, bagTag)
+	if err != nil {
+		return err
+	}
+
+	commitUser := ctx.Value(UserKey).(*User)
+
+	note := formatNote(commitUser, fmt.Sprintf("Loaned Bag Tag %s (%s) to %s, due %s",
+		bagTag,
+		strings.Replace(string(status.Type), "_", " ", -1),
+		student.Name(),
+		due.Format("01/02/06"),
+	), extraNote)
+
+	if err = assignDevice(ctx, bagTag, student.Name(), note); err != nil {
+		return err
+	}
+
+	if err = Loans.CreateLoan(ctx, bagTag, student.OtherID, student.Name(), commitUser.Username, due); err != nil {
+		return err
+	}
+
+	if _, err = verifyDevice(ctx, bagTag); err != nil {
+		return err
+	}
+
+	return auditDevice(ctx, AuditActionLoan, student.OtherID, bagTag, snapshot)
+}
+
+// GetLoanList returns a list of all Loans that haven't been returned.
+// If overdue is true, only Loans past their due date are returned
+func GetLoanList(ctx context.Context, overdue bool) ([]*Loan, error) {
+	return Loans.GetLoans(ctx, overdue)
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckoutLoaner(t *testing.T) {
+	ctx := oneRosterContext(t)
+	exec(t, ctx, "INSERT INTO devices(Bag_Tag, Status, User) VALUES ('1001', 'Checked Out', 'Jane Doe');")
+	exec(t, ctx, "INSERT INTO devices(Bag_Tag, Status, User) VALUES ('1002', 'Storage', '');")
+	exec(t, ctx, "INSERT INTO devices(Bag_Tag, Status, User) VALUES ('1003', 'Storage', '');")
+
+	due := time.Now().Add(24 * time.Hour)
+
+	if err := CheckoutLoaner(ctx, "100001", "1002", time.Now().Add(-time.Hour), ""); err == nil || !strings.Contains(err.Error(), "in the past") {
+		t.Errorf("CheckoutLoaner with a past due date = %v, want error", err)
+	}
+
+	//the student's primary device doesn't prevent a loaner
+	if err := CheckoutLoaner(ctx, "100001", "1002", due, ""); err != nil {
+		t.Fatal("CheckoutLoaner returned an error:", err)
+	}
+
+	//but an open loaner does
+	if err := CheckoutLoaner(ctx, "100001", "1003", due, ""); err == nil || !strings.Contains(err.Error(), "device checked out") {
+		t.Errorf("second CheckoutLoaner = %v, want error", err)
+	}
+
+	loans, err := GetLoanList(ctx, false)
+	if err != nil {
+		t.Fatal("GetLoanList returned an error:", err)
+	}
+	if len(loans) != 1 || loans[0].BagTag != "1002" || loans[0].OtherID != "100001" || loans[0].Name != "Jane Doe" || loans[0].Overdue() {
+		t.Fatalf("GetLoanList = %+v, want one loan of 1002 to Jane Doe", loans)
+	}
+
+	if loans, err = GetLoanList(ctx, true); err != nil || len(loans) != 0 {
+		t.Errorf("GetLoanList(overdue) = %+v, %v, want none", loans, err)
+	}
+
+	exec(t, ctx, "UPDATE loans SET due = ?;", time.Now().Add(-time.Hour))
+	if loans, err = GetLoanList(ctx, true); err != nil || len(loans) != 1 || !loans[0].Overdue() {
+		t.Errorf("GetLoanList(overdue) after due = %+v, %v, want one overdue loan", loans, err)
+	}
+
+	//checking in the loaner returns the loan
+	if err = CheckinDevice(ctx, "100001", "1002", false, nil, ""); err != nil {
+		t.Fatal("CheckinDevice returned an error:", err)
+	}
+	if loans, err = GetLoanList(ctx, false); err != nil || len(loans) != 0 {
+		t.Errorf("GetLoanList after checkin = %+v, %v, want none", loans, err)
+	}
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// OneRoster demographics.csv extension columns
+const (
+	OneRosterT2E2Column                      = "metadata.t2e2"
+	OneRosterEconomicallyDisadvantagedColumn = "metadata.economicallyDisadvantaged"
+)
+
+// OneRosterSource is a StudentSource that reads a OneRoster-style CSV export from Dir.
+// Students are read from users.csv (role "student"), with identifier as the OtherID and the first org as the campus.
+// If demographics.csv exists, the T2E2 status and economically disadvantaged flag are read from its
+// metadata.t2e2 and metadata.economicallyDisadvantaged columns. Files are read on every call,
+// so a RosterCache should be used for large exports
+type OneRosterSource struct {
+	Dir string
+}
+
+// readCSV reads the CSV file at path, returning each row as a map of header to value
+func readCSV(path string) ([]map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("Could not read header: %v", err)
+	}
+
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+	}
+
+	var rows []map[string]string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]string, len(header))
+		for i, h := range header {
+			if i < len(record) {
+				row[h] = strings.TrimSpace(record[i])
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// parseOneRosterGrade parses the first OneRoster grade code in grades
+func parseOneRosterGrade(grades string) (Grade, error) {
+	grade := strings.TrimSpace(strings.Split(grades, ",")[0])
+	switch strings.ToUpper(grade) {
+	case "IT", "PR", "PS":
+		return GradePreKindergarten, nil
+	}
+	return ParseGrade(grade)
+}
+
+// load reads all active students from the export
+func (o *OneRosterSource) load() ([]*Student, error) {
+	users, err := readCSV(filepath.Join(o.Dir, "users.csv"))
+	if err != nil {
+		return nil, &Error{Description: "Could not read OneRoster users.csv", Err: err}
+	}
+
+	demographics := make(map[string]map[string]string)
+	rows, err := readCSV(filepath.Join(o.Dir, "demographics.csv"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, &Error{Description: "Could not read OneRoster demographics.csv", Err: err}
+	}
+	for _, row := range rows {
+		demographics[row["sourcedId"]] = row
+	}
+
+	var students []*Student
+
+	for _, u := range users {
+		if !strings.EqualFold(u["role"], "student") || strings.EqualFold(u["enabledUser"], "false") {
+			continue
+		}
+		if status := u["status"]; status != "" && !strings.EqualFold(status, "active") {
+			continue
+		}
+		if u["identifier"] == "" {
+			continue
+		}
+
+		//students with grades that can't be mapped (e.g. ungraded) are skipped
+		grade, err := parseOneRosterGrade(u["grades"])
+		if err != nil {
+			continue
+		}
+
+		s := &Student{
+			FirstName: u["givenName"],
+			LastName:  u["familyName"],
+			OtherID:   u["identifier"],
+			Grade:     int(grade),
+			Campus:    strings.TrimSpace(strings.Split(u["orgSourcedIds"], ",")[0]),
+		}
+
+		if d, ok := demographics[u["sourcedId"]]; ok {
+			if t2e2 := d[OneRosterT2E2Column]; t2e2 != "" {
+				s.T2E2Status = &t2e2
+			}
+			if eco, err := strconv.ParseBool(d[OneRosterEconomicallyDisadvantagedColumn]); err == nil {
+				s.EconomicallyDisadvantaged = eco
+			}
+		}
+
+		students = append(students, s)
+	}
+
+	return students, nil
+}
+
+// GetStudent returns the Student with the given otherID
+func (o *OneRosterSource) GetStudent(_ context.Context, otherID string) (*Student, error) {
+	students, err := o.load()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range students {
+		if s.OtherID == otherID {
+			return s, nil
+		}
+	}
+
+	return nil, &Error{Description: fmt.Sprintf("Student could not be found with OtherID: %s", otherID), Err: nil, RequestError: true}
+}
+
+// GetStudentList returns a list of all Students
+func (o *OneRosterSource) GetStudentList(_ context.Context) ([]*Student, error) {
+	return o.load()
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeOneRoster writes the given files to a temporary directory and returns a OneRosterSource reading from it
+func writeOneRoster(t *testing.T, files map[string]string) *OneRosterSource {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0600); err != nil {
+			t.Fatal("Could not write", name, err)
+		}
+	}
+
+	return &OneRosterSource{Dir: dir}
+}
+
+func TestOneRosterSource(t *testing.T) {
+	source := writeOneRoster(t, map[string]string{
+		"users.csv": "\ufeffsourcedId,status,enabledUser,orgSourcedIds,role,username,givenName,familyName,identifier,grades\n" +
+			"u1,active,true,\"hs,ms\",student,jdoe,Jane,Doe,100001,\"09,10\"\n" +
+			"u2,active,true,es,student,jroe,John,Roe,100002,KG\n" +
+			"u3,active,true,es,student,apre,Ann,Pre,100003,PR\n" +
+			"u4,,true,es,student,bpk,Bob,Pk,100004,PK\n" +
+			//skipped: inactive, disabled, not a student, no identifier, and an unmapped grade
+			"u5,tobedeleted,true,hs,student,cold,Cat,Old,100005,11\n" +
+			"u6,active,false,hs,student,doff,Dan,Off,100006,11\n" +
+			"u7,active,true,hs,teacher,eteach,Eve,Teach,100007,\n" +
+			"u8,active,true,hs,student,fnone,Fay,None,,11\n" +
+			"u9,active,true,hs,student,gung,Gus,Ung,100009,UG\n",
+		"demographics.csv": "sourcedId,metadata.t2e2,metadata.economicallyDisadvantaged\n" +
+			"u1,Yes,true\n" +
+			"u2,No,false\n" +
+			"u3,,notabool\n",
+	})
+
+	students, err := source.GetStudentList(context.Background())
+	if err != nil {
+		t.Fatal("GetStudentList returned an error:", err)
+	}
+
+	yes, no := "Yes", "No"
+	want := []*Student{
+		{FirstName: "Jane", LastName: "Doe", OtherID: "100001", Grade: 9, Campus: "hs", T2E2Status: &yes, EconomicallyDisadvantaged: true},
+		{FirstName: "John", LastName: "Roe", OtherID: "100002", Grade: int(GradeKindergarten), Campus: "es", T2E2Status: &no},
+		{FirstName: "Ann", LastName: "Pre", OtherID: "100003", Grade: int(GradePreKindergarten), Campus: "es"},
+		{FirstName: "Bob", LastName: "Pk", OtherID: "100004", Grade: int(GradePreKindergarten), Campus: "es"},
+	}
+	if !reflect.DeepEqual(students, want) {
+		for _, s := range students {
+			t.Logf("%+v", s)
+		}
+		t.Errorf("GetStudentList returned %d students, want %d", len(students), len(want))
+	}
+
+	s, err := source.GetStudent(context.Background(), "100002")
+	if err != nil {
+		t.Fatal("GetStudent returned an error:", err)
+	}
+	if !reflect.DeepEqual(s, want[1]) {
+		t.Errorf("GetStudent = %+v, want %+v", s, want[1])
+	}
+
+	if _, err = source.GetStudent(context.Background(), "100005"); err == nil || !err.(*Error).RequestError {
+		t.Errorf("GetStudent of an inactive student = %v, want a request error", err)
+	}
+}
+
+func TestOneRosterSourceFiles(t *testing.T) {
+	//demographics.csv is optional
+	source := writeOneRoster(t, map[string]string{
+		"users.csv": "sourcedId,role,givenName,familyName,identifier,grades\n" +
+			"u1,student,Jane,Doe,100001,12\n",
+	})
+	students, err := source.GetStudentList(context.Background())
+	if err != nil {
+		t.Fatal("GetStudentList without demographics.csv returned an error:", err)
+	}
+	if len(students) != 1 || students[0].T2E2Status != nil || students[0].Grade != 12 {
+		t.Errorf("GetStudentList = %+v, want one grade 12 student with no T2E2 status", students)
+	}
+
+	source = writeOneRoster(t, nil)
+	if _, err = source.GetStudentList(context.Background()); err == nil || err.(*Error).RequestError {
+		t.Errorf("GetStudentList without users.csv = %v, want a server error", err)
+	}
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Payment methods
+const (
+	PaymentMethodCash   = "cash"
+	PaymentMethodCheck  = "check"
+	PaymentMethodOnline = "online"
+)
+
+// Payment represents a payment made against a charge
+type Payment struct {
+	ID            int     `json:"id"`
+	ChargeID      int     `json:"charge_id"`
+	OtherID       string  `json:"other_id"`
+	Name          string  `json:"name"`
+	Amount        float32 `json:"amount"`
+	Method        string  `json:"method"`
+	ReceiptNumber string  `json:"receipt_number,omitempty"`
+	//StatusType is the student's status after the payment
+	StatusType  StatusType `json:"status_type"`
+	Username    string     `json:"username"`
+	DisplayName string     `json:"display_name"`
+	Date        time.Time  `json:"date"`
+}
+
+// RecordPayment records a payment of amount by the student with the given otherID against the charge with the given id,
+// adding it to the charge's amount paid. The recorded Payment and the student's new Status are returned
+func RecordPayment(ctx context.Context, chargeID int, otherID string, amount float32, method, receiptNumber string) (*Payment, *Status, error) {
+	switch method {
+	case PaymentMethodCash, PaymentMethodCheck, PaymentMethodOnline:
+	default:
+		return nil, nil, &Error{Description: fmt.Sprintf(`Invalid payment method "%s" (must be cash, check, or online)`, method), Err: nil, RequestError: true}
+	}
+
+	//round to cents
+	amount = float32(int(amount*100+0.5)) / 100
+	if amount <= 0 {
+		return nil, nil, &Error{Description: "Payment amount must be positive", Err: nil, RequestError: true}
+	}
+
+	student, err := GetStudent(ctx, otherID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	charge, err := Charges.GetCharge(ctx, chargeID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if charge == nil {
+		return nil, nil, &Error{Description: fmt.Sprintf("Charge %d doesn't exist", chargeID), Err: nil, RequestError: true}
+	}
+
+	if nameKey(charge.User) != nameKey(student.Name()) {
+		return nil, nil, &Error{Description: fmt.Sprintf(`Charge %d is not for %s (User is "%s")`, chargeID, student.Name(), charge.User), Err: nil, RequestError: true}
+	}
+
+	if balance := charge.AmountCharged() - charge.AmountPaid; amount > balance+0.005 {
+		return nil, nil, &Error{Description: fmt.Sprintf("Payment of $%.2f is more than the balance of $%.2f", amount, balance), Err: nil, RequestError: true}
+	}
+
+	commitUser := ctx.Value(UserKey).(*User)
+
+	receiptNumber = strings.TrimSpace(receiptNumber)
+
+	text := fmt.Sprintf("Payment of $%.2f by %s", amount, method)
+	if receiptNumber != "" {
+		text += fmt.Sprintf(", Receipt %s", receiptNumber)
+	}
+
+	ok, err := Charges.AddPayment(ctx, chargeID, amount, charge.AmountCharged(), formatNote(commitUser, text, ""))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	//the charge exists, so another payment was made since it was read
+	if !ok {
+		return nil, nil, &Error{Description: fmt.Sprintf("Payment of $%.2f is more than the balance of Charge %d", amount, chargeID), Err: nil, RequestError: true}
+	}
+
+	//status is checked after the charge is updated so the payment counts for the student
+	status, err := student.Status(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p := &Payment{
+		ChargeID:      chargeID,
+		OtherID:       student.OtherID,
+		Name:          student.Name(),
+		Amount:        amount,
+		Method:        method,
+		ReceiptNumber: receiptNumber,
+		StatusType:    status.Type,
+		Username:      commitUser.Username,
+		DisplayName:   commitUser.DisplayName,
+		Date:          time.Now(),
+	}
+
+	if p.ID, err = Payments.CreatePayment(ctx, p); err != nil {
+		return nil, nil, err
+	}
+
+	err = Audit(ctx, &AuditEvent{
+		Action:  AuditActionPayment,
+		OtherID: student.OtherID,
+		Before:  map[string]float32{"paid": charge.AmountPaid},
+		After:   map[string]interface{}{"paid": charge.AmountPaid + amount, "payment_id": p.ID, "charge_id": chargeID},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return p, status, nil
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Receipt represents the information printed on a checkout or payment receipt
+type Receipt struct {
+	ID           int
+	Date         time.Time
+	OtherID      string
+	Name         string
+	BagTag       string
+	SerialNumber string
+	Model        string
+	StatusType   StatusType
+	//DisplayName is the name of the user who made the checkout or payment
+	DisplayName string
+
+	//the following are only set for payment receipts
+	Charges       []*Damage
+	Charged       float32
+	Amount        float32
+	Method        string
+	ReceiptNumber string
+}
+
+// GetCheckoutReceipt returns the Receipt for the checkout with the given id, or nil if it doesn't exist
+func GetCheckoutReceipt(ctx context.Context, id int) (*Receipt, error) {
+	r, undone, err := Checkouts.GetCheckoutReceipt(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if undone {
+		return nil, &Error{Description: fmt.Sprintf("Checkout %d was undone", id), Err: nil, RequestError: true}
+	}
+
+	return r, nil
+}
+
+// GetPaymentReceipt returns the Receipt for the payment with the given id, or nil if it doesn't exist
+func GetPaymentReceipt(ctx context.Context, id int) (*Receipt, error) {
+	return Payments.GetPaymentReceipt(ctx, id)
+}
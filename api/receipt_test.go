@@ -0,0 +1,87 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCheckoutReceipt(t *testing.T) {
+	ctx := oneRosterContext(t)
+	exec(t, ctx, "INSERT INTO devices(Inventory_Number, Serial_Number, Bag_Tag, Status, User, Model) VALUES ('INV1', 'SER1', '1001', 'Storage', '', 'Chromebook');")
+
+	id, err := CheckoutDevice(ctx, "100001", "1001", "")
+	if err != nil {
+		t.Fatal("CheckoutDevice returned an error:", err)
+	}
+
+	r, err := GetCheckoutReceipt(ctx, id)
+	if err != nil {
+		t.Fatal("GetCheckoutReceipt returned an error:", err)
+	}
+	if r == nil {
+		t.Fatal("GetCheckoutReceipt = nil, want a receipt")
+	}
+	if r.ID != id || r.OtherID != "100001" || r.Name != "Jane Doe" || r.BagTag != "1001" || r.SerialNumber != "SER1" ||
+		r.Model != "Chromebook" || r.StatusType != StatusTypeBlackBag || r.DisplayName != "Tech Person" {
+		t.Errorf("GetCheckoutReceipt = %+v, want a black bag checkout of 1001 to Jane Doe by Tech Person", r)
+	}
+	if time.Since(r.Date) > time.Minute {
+		t.Errorf("GetCheckoutReceipt date = %v, want now", r.Date)
+	}
+	if r.Charges != nil || r.Amount != 0 || r.Method != "" {
+		t.Errorf("GetCheckoutReceipt = %+v, want no payment fields", r)
+	}
+
+	if r, err = GetCheckoutReceipt(ctx, id+1); r != nil || err != nil {
+		t.Errorf("GetCheckoutReceipt of a missing checkout = %+v, %v, want nil", r, err)
+	}
+
+	if err = UndoCheckout(ctx, "100001", "1001"); err != nil {
+		t.Fatal("UndoCheckout returned an error:", err)
+	}
+	if r, err = GetCheckoutReceipt(ctx, id); r != nil || err == nil || !err.(*Error).RequestError {
+		t.Errorf("GetCheckoutReceipt of an undone checkout = %+v, %v, want a request error", r, err)
+	}
+}
+
+func TestPaymentReceipt(t *testing.T) {
+	ctx := oneRosterContext(t)
+	exec(t, ctx, "INSERT INTO devices(Inventory_Number, Serial_Number, Bag_Tag, Status, User, Model) VALUES ('INV1', 'SER1', '1001', 'Checked Out', 'Jane Doe', 'Chromebook');")
+
+	if err := CheckinDevice(ctx, "100001", "1001", false, []*Damage{{Description: "Screen", Amount: 45}, {Description: "Keyboard", Amount: 30}}, ""); err != nil {
+		t.Fatal("CheckinDevice returned an error:", err)
+	}
+
+	charges, err := Charges.GetCharges(ctx, "Jane Doe")
+	if err != nil || len(charges) != 1 {
+		t.Fatalf("GetCharges = %+v, %v, want one charge", charges, err)
+	}
+
+	payment, _, err := RecordPayment(ctx, charges[0].ID, "100001", 40, PaymentMethodCheck, "R-123")
+	if err != nil {
+		t.Fatal("RecordPayment returned an error:", err)
+	}
+
+	r, err := GetPaymentReceipt(ctx, payment.ID)
+	if err != nil {
+		t.Fatal("GetPaymentReceipt returned an error:", err)
+	}
+	if r == nil {
+		t.Fatal("GetPaymentReceipt = nil, want a receipt")
+	}
+	if r.ID != payment.ID || r.OtherID != "100001" || r.Name != "Jane Doe" || r.BagTag != "1001" || r.SerialNumber != "SER1" ||
+		r.DisplayName != "Tech Person" || r.StatusType != payment.StatusType {
+		t.Errorf("GetPaymentReceipt = %+v, want a payment for Jane Doe's 1001 received by Tech Person", r)
+	}
+	if r.Amount != 40 || r.Charged != 75 || r.Method != PaymentMethodCheck || r.ReceiptNumber != "R-123" {
+		t.Errorf("GetPaymentReceipt = %+v, want a check for 40.00 of 75.00 with receipt R-123", r)
+	}
+	if want := []*Damage{{Description: "Screen", Amount: 45}, {Description: "Keyboard", Amount: 30}}; !reflect.DeepEqual(r.Charges, want) {
+		t.Errorf("GetPaymentReceipt charges = %+v, want %+v", r.Charges, want)
+	}
+
+	if r, err = GetPaymentReceipt(ctx, payment.ID+1); r != nil || err != nil {
+		t.Errorf("GetPaymentReceipt of a missing payment = %+v, %v, want nil", r, err)
+	}
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"time"
+)
+
+// DeviceRepository is an interface to the devices and verifications in the inventory database.
+// Methods use the inventory transaction in the context
+type DeviceRepository interface {
+	//GetDevice returns the Device with the given bagTag, or nil if it doesn't exist
+	GetDevice(ctx context.Context, bagTag string) (*Device, error)
+
+	//GetDeviceBySerialNumber returns the Device with the given serialNumber, or nil if it doesn't exist.
+	//A request error is returned if more than one device has serialNumber
+	GetDeviceBySerialNumber(ctx context.Context, serialNumber string) (*Device, error)
+
+	//GetDeviceByInventoryNumber returns the Device with the given inventoryNumber, or nil if it doesn't exist
+	GetDeviceByInventoryNumber(ctx context.Context, inventoryNumber string) (*Device, error)
+
+	//GetDevices returns the devices assigned to the user with the given name
+	GetDevices(ctx context.Context, name string) ([]*Device, error)
+
+	//GetDeviceIDs returns the ids of the devices assigned to the user with the given name
+	GetDeviceIDs(ctx context.Context, name string) ([]int, error)
+
+	//GetDeviceIDMap returns the ids of all devices assigned to a user, keyed by nameKey of the user
+	GetDeviceIDMap(ctx context.Context) (map[string][]int, error)
+
+	//AssignDevice sets the User of the device in Storage with the given bagTag to name and the Status to Checked Out,
+	//appending note to the notes field. ok is false if no device was updated
+	AssignDevice(ctx context.Context, bagTag, name, note string) (ok bool, err error)
+
+	//UnassignDevice clears the User of the device with the given bagTag that is Checked Out to name,
+	//setting the Status to status and appending note to the notes field. ok is false if no device was updated
+	UnassignDevice(ctx context.Context, bagTag, name, status, note string) (ok bool, err error)
+
+	//RestoreDevice sets the User, Status, and Notes of the device with the given id if it is still Checked Out to name.
+	//ok is false if no device was updated
+	RestoreDevice(ctx context.Context, id int, name, user, status, notes string) (ok bool, err error)
+
+	//VerifyDevice records a verification of the device with the given bagTag by username at date,
+	//returning the id of the verification
+	VerifyDevice(ctx context.Context, bagTag, username string, date time.Time) (id int64, err error)
+
+	//DeleteVerification deletes the verification with the given id
+	DeleteVerification(ctx context.Context, id int64) error
+
+	//GetVerifications returns the verifications of the device with the given id, oldest first
+	GetVerifications(ctx context.Context, deviceID int) ([]*Verification, error)
+}
+
+// ChargeRepository is an interface to the charges in the inventory database.
+// Methods use the inventory transaction in the context
+type ChargeRepository interface {
+	//GetCharges returns the charges for the user with the given name
+	GetCharges(ctx context.Context, name string) ([]*Charge, error)
+
+	//GetChargeMap returns all charges with a user, keyed by nameKey of the user
+	GetChargeMap(ctx context.Context) (map[string][]*Charge, error)
+
+	//CreateCharge creates a charge to the user with the given name for the device with the given bagTag.
+	//charges is in the format parsed by Charge.AmountCharged and Charge.Description.
+	//ok is false if the device doesn't exist
+	CreateCharge(ctx context.Context, bagTag, name, charges, note string) (ok bool, err error)
+
+	//GetCharge returns the Charge with the given id, or nil if it doesn't exist
+	GetCharge(ctx context.Context, id int) (*Charge, error)
+
+	//AddPayment adds amount to the amount paid of the charge with the given id, appending note to the notes field.
+	//The check that the new amount paid isn't more than charged is made in the same statement, so concurrent payments can't overpay.
+	//ok is false if the charge doesn't exist or the payment is more than its balance
+	AddPayment(ctx context.Context, id int, amount, charged float32, note string) (ok bool, err error)
+}
+
+// LoanRepository is an interface to the loans in the inventory database.
+// Methods use the inventory transaction in the context
+type LoanRepository interface {
+	//GetLoanDeviceIDs returns the ids of the devices on loan to the student with the given otherID
+	GetLoanDeviceIDs(ctx context.Context, otherID string) (map[int]bool, error)
+
+	//CreateLoan records a loan of the device with the given bagTag to the student with the given otherID and name, due at due
+	CreateLoan(ctx context.Context, bagTag, otherID, name, username string, due time.Time) error
+
+	//ReturnLoan marks any open loan for the device with the given bagTag as returned
+	ReturnLoan(ctx context.Context, bagTag string) error
+
+	//GetLoans returns the Loans that haven't been returned, soonest due first.
+	//If overdue is true, only Loans past their due date are returned
+	GetLoans(ctx context.Context, overdue bool) ([]*Loan, error)
+}
+
+// CheckoutRepository is an interface to the checkouts in the inventory database.
+// Methods use the inventory transaction in the context
+type CheckoutRepository interface {
+	//CreateCheckout records c, returning its id
+	CreateCheckout(ctx context.Context, c *Checkout) (id int, err error)
+
+	//GetLastCheckout returns the most recent Checkout of the device with the given bagTag to the student with the given otherID
+	//that hasn't been undone, or nil if it doesn't exist
+	GetLastCheckout(ctx context.Context, bagTag, otherID string) (*Checkout, error)
+
+	//UndoCheckout marks the checkout with the given id as undone at date
+	UndoCheckout(ctx context.Context, id int, date time.Time) error
+
+	//GetCheckoutReceipt returns the Receipt for the checkout with the given id, or nil if it doesn't exist.
+	//undone is true if the checkout was undone
+	GetCheckoutReceipt(ctx context.Context, id int) (r *Receipt, undone bool, err error)
+}
+
+// PaymentRepository is an interface to the payments in the inventory database.
+// Methods use the inventory transaction in the context
+type PaymentRepository interface {
+	//CreatePayment records p, returning its id
+	CreatePayment(ctx context.Context, p *Payment) (id int, err error)
+
+	//GetPaymentReceipt returns the Receipt for the payment with the given id, or nil if it doesn't exist
+	GetPaymentReceipt(ctx context.Context, id int) (*Receipt, error)
+}
+
+// Devices is the DeviceRepository used by the API
+var Devices DeviceRepository = NewMySQLRepository()
+
+// Charges is the ChargeRepository used by the API
+var Charges ChargeRepository = NewMySQLRepository()
+
+// Loans is the LoanRepository used by the API
+var Loans LoanRepository = NewMySQLRepository()
+
+// Checkouts is the CheckoutRepository used by the API
+var Checkouts CheckoutRepository = NewMySQLRepository()
+
+// Payments is the PaymentRepository used by the API
+var Payments PaymentRepository = NewMySQLRepository()
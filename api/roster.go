@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// RosterCache is an in-memory cache of the student roster
+type RosterCache struct {
+	source    StudentSource
+	interval  time.Duration
+	students  []*Student
+	index     map[string]*Student
+	refreshed time.Time
+	mu        *sync.RWMutex
+}
+
+// Roster is the RosterCache used by GetStudent and GetStudentList. If nil, Students is queried for every request
+var Roster *RosterCache
+
+// refreshRoster refreshes the roster every interval
+func refreshRoster(c *RosterCache) {
+	for {
+		time.Sleep(c.interval)
+		if err := c.Refresh(); err != nil {
+			log.Println("Could not refresh roster:", err)
+		}
+	}
+}
+
+// NewRosterCache returns a new RosterCache that loads students from the given StudentSource
+// and refreshes them every interval. An error is returned if the initial load fails
+func NewRosterCache(source StudentSource, interval time.Duration) (*RosterCache, error) {
+	c := &RosterCache{
+		source:   source,
+		interval: interval,
+		index:    make(map[string]*Student),
+		mu:       new(sync.RWMutex),
+	}
+
+	if err := c.Refresh(); err != nil {
+		return nil, err
+	}
+
+	go refreshRoster(c)
+	return c, nil
+}
+
+// Refresh reloads the roster from its StudentSource
+func (c *RosterCache) Refresh() error {
+	students, err := c.source.GetStudentList(context.Background())
+	if err != nil {
+		return err
+	}
+
+	index := make(map[string]*Student, len(students))
+	for _, s := range students {
+		index[s.OtherID] = s
+	}
+
+	c.mu.Lock()
+	c.students = students
+	c.index = index
+	c.refreshed = time.Now()
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Refreshed returns the time the roster was last refreshed
+func (c *RosterCache) Refreshed() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.refreshed
+}
+
+// get returns a copy of the cached Student with the given otherID, or nil if it isn't cached
+func (c *RosterCache) get(otherID string) *Student {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if s, ok := c.index[otherID]; ok {
+		stu := *s
+		return &stu
+	}
+	return nil
+}
+
+// add caches s until the next refresh
+func (c *RosterCache) add(s *Student) {
+	stu := *s
+	c.mu.Lock()
+	c.index[s.OtherID] = &stu
+	c.mu.Unlock()
+}
+
+// list returns a copy of the cached Students
+func (c *RosterCache) list() []*Student {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	students := make([]*Student, 0, len(c.students))
+	for _, s := range c.students {
+		stu := *s
+		students = append(students, &stu)
+	}
+	return students
+}
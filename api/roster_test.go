@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// testSource is a StudentSource backed by a map of students, counting the calls made to it
+type testSource struct {
+	students map[string]*Student
+	err      error
+	gets     int
+	lists    int
+}
+
+func (t *testSource) GetStudent(ctx context.Context, otherID string) (*Student, error) {
+	t.gets++
+	if s, ok := t.students[otherID]; ok {
+		stu := *s
+		return &stu, nil
+	}
+	return nil, &Error{Description: "Student does not exist", Err: nil, RequestError: true}
+}
+
+func (t *testSource) GetStudentList(ctx context.Context) ([]*Student, error) {
+	t.lists++
+	if t.err != nil {
+		return nil, t.err
+	}
+	var students []*Student
+	for _, s := range t.students {
+		stu := *s
+		students = append(students, &stu)
+	}
+	return students, nil
+}
+
+// useRoster sets Students and Roster for the length of the test
+func useRoster(t *testing.T, source StudentSource, roster *RosterCache) {
+	t.Helper()
+	students, r := Students, Roster
+	Students, Roster = source, roster
+	t.Cleanup(func() { Students, Roster = students, r })
+}
+
+func TestRosterCache(t *testing.T) {
+	source := &testSource{students: map[string]*Student{
+		"100001": {FirstName: "Jane", LastName: "Doe", OtherID: "100001", Grade: 9},
+	}}
+
+	roster, err := NewRosterCache(source, time.Hour)
+	if err != nil {
+		t.Fatal("NewRosterCache returned an error:", err)
+	}
+	if source.lists != 1 || roster.Refreshed().IsZero() {
+		t.Errorf("NewRosterCache made %d list calls, refreshed at %v, want 1 call and a refresh time", source.lists, roster.Refreshed())
+	}
+	useRoster(t, source, roster)
+
+	ctx := context.Background()
+
+	s, err := GetStudent(ctx, "100001")
+	if err != nil {
+		t.Fatal("GetStudent returned an error:", err)
+	}
+	if s.Name() != "Jane Doe" || source.gets != 0 {
+		t.Errorf("GetStudent = %+v with %d source calls, want Jane Doe from the roster", s, source.gets)
+	}
+
+	//changes to returned students don't change the cache
+	s.Grade = 12
+	if s, _ = GetStudent(ctx, "100001"); s.Grade != 9 {
+		t.Errorf("GetStudent grade = %d after changing a returned Student, want 9", s.Grade)
+	}
+
+	//students added since the last refresh fall back to the source and are cached
+	source.students["100002"] = &Student{FirstName: "John", LastName: "Roe", OtherID: "100002", Grade: 10}
+	for i := 0; i < 2; i++ {
+		if s, err = GetStudent(ctx, "100002"); err != nil || s.Name() != "John Roe" {
+			t.Fatalf("GetStudent = %+v, %v, want John Roe", s, err)
+		}
+	}
+	if source.gets != 1 {
+		t.Errorf("GetStudent made %d source calls, want 1", source.gets)
+	}
+
+	if _, err = GetStudent(ctx, "100003"); err == nil {
+		t.Error("GetStudent of a missing student didn't return an error")
+	}
+
+	list, err := GetStudentList(ctx)
+	if err != nil {
+		t.Fatal("GetStudentList returned an error:", err)
+	}
+	if len(list) != 1 || source.lists != 1 {
+		t.Errorf("GetStudentList = %d students with %d source calls, want the 1 cached student", len(list), source.lists)
+	}
+
+	if err = roster.Refresh(); err != nil {
+		t.Fatal("Refresh returned an error:", err)
+	}
+	if list, _ = GetStudentList(ctx); len(list) != 2 {
+		t.Errorf("GetStudentList after Refresh = %d students, want 2", len(list))
+	}
+
+	//a failed refresh keeps the cached roster
+	source.err = errors.New("source unavailable")
+	if err = roster.Refresh(); err == nil {
+		t.Error("Refresh didn't return the source's error")
+	}
+	if list, _ = GetStudentList(ctx); len(list) != 2 {
+		t.Errorf("GetStudentList after a failed Refresh = %d students, want 2", len(list))
+	}
+}
+
+func TestNewRosterCacheError(t *testing.T) {
+	if _, err := NewRosterCache(&testSource{err: errors.New("source unavailable")}, time.Hour); err == nil {
+		t.Error("NewRosterCache didn't return the initial load's error")
+	}
+}
@@ -0,0 +1,538 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+)
+
+// RuleSubject is what a Rule is evaluated against
+type RuleSubject string
+
+// Rule subjects
+const (
+	RuleSubjectStudent RuleSubject = "student"
+	RuleSubjectDevice  RuleSubject = "device"
+	RuleSubjectCharge  RuleSubject = "charge"
+)
+
+// T2E2Missing matches a student that hasn't completed the T2E2 Agreement
+const T2E2Missing = "missing"
+
+// RuleMatch is the set of conditions for a Rule. A nil condition is ignored
+type RuleMatch struct {
+	//T2E2 matches the T2E2 status exactly, or T2E2Missing if it hasn't been completed
+	T2E2                      *string  `json:"t2e2,omitempty"`
+	GradeMin                  *Grade   `json:"grade_min,omitempty"`
+	GradeMax                  *Grade   `json:"grade_max,omitempty"`
+	Campuses                  []string `json:"campuses,omitempty"`
+	EconomicallyDisadvantaged *bool    `json:"economically_disadvantaged,omitempty"`
+	DevicesMin                *int     `json:"devices_min,omitempty"`
+	OpenChargesMax            *int     `json:"open_charges_max,omitempty"`
+
+	//PaidRatioMin and PaidRatioBelow only apply to charge rules
+	PaidRatioMin   *float32 `json:"paid_ratio_min,omitempty"`
+	PaidRatioBelow *float32 `json:"paid_ratio_below,omitempty"`
+}
+
+// Rule is an eligibility rule. If a Rule matches, Status limits the student's StatusType
+// and Issue is added to the student's Issues. Device and charge rules are evaluated once per device or open charge
+type Rule struct {
+	Name    string      `json:"name"`
+	Subject RuleSubject `json:"subject,omitempty"`
+	Match   RuleMatch   `json:"match"`
+	Status  StatusType  `json:"status,omitempty"`
+	Issue   string      `json:"issue,omitempty"`
+}
+
+// RuleSet is an ordered list of Rules used to compute a student's Status
+type RuleSet struct {
+	//DefaultStatus is the StatusType given if no Rules limit it
+	DefaultStatus StatusType `json:"default_status"`
+	//ChargeSlack is the amount a charge can be off by to account for rounding errors
+	ChargeSlack float32 `json:"charge_slack"`
+	Rules       []*Rule `json:"rules"`
+	//Policies are applied after Rules
+	Policies []*Policy `json:"policies,omitempty"`
+}
+
+// Policy limits the StatusType of students by campus and grade
+type Policy struct {
+	Name string `json:"name"`
+	//Campuses matches the student's campus (Skyward entity). An empty list matches all campuses
+	Campuses  []string   `json:"campuses,omitempty"`
+	GradeMin  *Grade     `json:"grade_min,omitempty"`
+	GradeMax  *Grade     `json:"grade_max,omitempty"`
+	MaxStatus StatusType `json:"max_status"`
+	Issue     string     `json:"issue"`
+}
+
+// Rules is the RuleSet used to compute Student Statuses
+var Rules = DefaultRuleSet()
+
+func intPtr(i int) *int             { return &i }
+func float32Ptr(f float32) *float32 { return &f }
+func stringPtr(s string) *string    { return &s }
+func gradePtr(g Grade) *Grade       { return &g }
+
+// DefaultRuleSet returns the default RuleSet
+func DefaultRuleSet() *RuleSet {
+	return &RuleSet{
+		DefaultStatus: StatusTypeBlackBag,
+		ChargeSlack:   1,
+		Rules: []*Rule{
+			{
+				Name:   "t2e2_missing",
+				Match:  RuleMatch{T2E2: stringPtr(T2E2Missing)},
+				Status: StatusTypeNone,
+				Issue:  "T2E2 Agreement not completed",
+			},
+			{
+				Name:    "device_checked_out",
+				Subject: RuleSubjectDevice,
+				Status:  StatusTypeNone,
+				Issue:   "Student has device checked out",
+			},
+			{
+				Name:    "charge_less_than_half_paid",
+				Subject: RuleSubjectCharge,
+				Match:   RuleMatch{PaidRatioBelow: float32Ptr(0.5)},
+				Status:  StatusTypeNone,
+				Issue:   "Student has charge with less than 50% paid",
+			},
+			{
+				Name:    "charge_unpaid",
+				Subject: RuleSubjectCharge,
+				Match:   RuleMatch{PaidRatioMin: float32Ptr(0.5)},
+				Status:  StatusTypeRedBag,
+				Issue:   "Student has unpaid charge",
+			},
+			{
+				Name:   "t2e2_no_take_home",
+				Match:  RuleMatch{T2E2: stringPtr("No"), OpenChargesMax: intPtr(0)},
+				Status: StatusTypeRedBag,
+				Issue:  "T2E2 Agreement does not permit student to take home device",
+			},
+		},
+		Policies: []*Policy{
+			{
+				Name:      "elementary_classroom_only",
+				GradeMin:  gradePtr(GradePreKindergarten),
+				GradeMax:  gradePtr(5),
+				MaxStatus: StatusTypeClassroomOnly,
+				Issue:     "Campus policy does not permit student to take home device",
+			},
+		},
+	}
+}
+
+// statusRank returns the rank of the given StatusType. Lower ranks are more restrictive
+func statusRank(t StatusType) int {
+	switch t {
+	case StatusTypeNone:
+		return 0
+	case StatusTypeClassroomOnly:
+		return 1
+	case StatusTypeRedBag:
+		return 2
+	case StatusTypeBlackBag:
+		return 3
+	}
+	return -1
+}
+
+// LoadRuleSet reads a RuleSet from the JSON file at path
+func LoadRuleSet(path string) (*RuleSet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Could not open rules file: %v", err)
+	}
+	defer f.Close()
+
+	r := new(RuleSet)
+	d := json.NewDecoder(f)
+	d.DisallowUnknownFields()
+	if err = d.Decode(r); err != nil {
+		return nil, fmt.Errorf("Could not decode rules file: %v", err)
+	}
+
+	if err = r.validate(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// validate checks the RuleSet for invalid values
+func (r *RuleSet) validate() error {
+	if statusRank(r.DefaultStatus) == -1 {
+		return fmt.Errorf("Invalid default_status: %s", r.DefaultStatus)
+	}
+
+	if r.ChargeSlack < 0 {
+		return fmt.Errorf("Invalid charge_slack: %v", r.ChargeSlack)
+	}
+
+	for idx, rule := range r.Rules {
+		if rule == nil {
+			return fmt.Errorf("Rule %d is empty", idx)
+		}
+		if rule.Name == "" {
+			return fmt.Errorf("Rule %d has no name", idx)
+		}
+		switch rule.Subject {
+		case "", RuleSubjectStudent, RuleSubjectDevice:
+			if rule.Match.PaidRatioMin != nil || rule.Match.PaidRatioBelow != nil {
+				return fmt.Errorf("Rule %s: paid ratio conditions only apply to charge rules", rule.Name)
+			}
+		case RuleSubjectCharge:
+		default:
+			return fmt.Errorf("Rule %s: invalid subject: %s", rule.Name, rule.Subject)
+		}
+		if rule.Status != "" && statusRank(rule.Status) == -1 {
+			return fmt.Errorf("Rule %s: invalid status: %s", rule.Name, rule.Status)
+		}
+		if rule.Status != "" && rule.Issue == "" {
+			return fmt.Errorf("Rule %s: rules with a status must have an issue", rule.Name)
+		}
+	}
+
+	for idx, policy := range r.Policies {
+		if policy == nil {
+			return fmt.Errorf("Policy %d is empty", idx)
+		}
+		if policy.Name == "" {
+			return fmt.Errorf("Policy %d has no name", idx)
+		}
+		if statusRank(policy.MaxStatus) == -1 {
+			return fmt.Errorf("Policy %s: invalid max_status: %s", policy.Name, policy.MaxStatus)
+		}
+		if policy.Issue == "" {
+			return fmt.Errorf("Policy %s has no issue", policy.Name)
+		}
+	}
+
+	return nil
+}
+
+// matchGrade returns true if grade is between min and max, inclusive. A nil min or max is ignored
+func matchGrade(min, max *Grade, grade int) bool {
+	if min != nil && grade < int(*min) {
+		return false
+	}
+	if max != nil && grade > int(*max) {
+		return false
+	}
+	return true
+}
+
+// matchCampus returns true if campuses is empty or contains campus
+func matchCampus(campuses []string, campus string) bool {
+	if len(campuses) == 0 {
+		return true
+	}
+	for _, c := range campuses {
+		if c == campus {
+			return true
+		}
+	}
+	return false
+}
+
+// chargeOpen returns true if the charge isn't paid off
+func (r *RuleSet) chargeOpen(c *Charge) bool {
+	return math.Abs(float64(c.AmountCharged()-c.AmountPaid)) >= float64(r.ChargeSlack)
+}
+
+// matchStudent returns true if the student conditions of m match
+func (r *RuleSet) matchStudent(m *RuleMatch, s *Student, devices []int, charges []*Charge) bool {
+	if m.T2E2 != nil {
+		if *(m.T2E2) == T2E2Missing {
+			if s.T2E2Status != nil {
+				return false
+			}
+		} else if s.T2E2Status == nil || *(s.T2E2Status) != *(m.T2E2) {
+			return false
+		}
+	}
+
+	if !matchGrade(m.GradeMin, m.GradeMax, s.Grade) {
+		return false
+	}
+
+	if !matchCampus(m.Campuses, s.Campus) {
+		return false
+	}
+
+	if m.EconomicallyDisadvantaged != nil && s.EconomicallyDisadvantaged != *(m.EconomicallyDisadvantaged) {
+		return false
+	}
+
+	if m.DevicesMin != nil && len(devices) < *(m.DevicesMin) {
+		return false
+	}
+
+	if m.OpenChargesMax != nil {
+		open := 0
+		for _, c := range charges {
+			if r.chargeOpen(c) {
+				open++
+			}
+		}
+		if open > *(m.OpenChargesMax) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchCharge returns true if the charge conditions of m match c
+func (r *RuleSet) matchCharge(m *RuleMatch, c *Charge) bool {
+	if !r.chargeOpen(c) {
+		return false
+	}
+
+	if m.PaidRatioMin != nil && c.AmountPaid < c.AmountCharged()*(*(m.PaidRatioMin))-r.ChargeSlack {
+		return false
+	}
+
+	if m.PaidRatioBelow != nil && c.AmountPaid >= c.AmountCharged()*(*(m.PaidRatioBelow))-r.ChargeSlack {
+		return false
+	}
+
+	return true
+}
+
+// Check is a single check evaluated while computing a Status
+type Check struct {
+	Name      string      `json:"name"`
+	Type      string      `json:"type"`
+	Subject   RuleSubject `json:"subject"`
+	SubjectID int         `json:"subject_id,omitempty"`
+	//Passed is false if the check matched and applies to the student
+	Passed bool `json:"passed"`
+	//Status is the StatusType the check limits the student to if it fails
+	Status     StatusType             `json:"status,omitempty"`
+	Issue      string                 `json:"issue,omitempty"`
+	Values     map[string]interface{} `json:"values"`
+	Thresholds interface{}            `json:"thresholds,omitempty"`
+}
+
+// Check types
+const (
+	CheckTypeRule   = "rule"
+	CheckTypePolicy = "policy"
+)
+
+// Explanation explains how a Status was computed
+type Explanation struct {
+	Status      *Status  `json:"status"`
+	ChargeSlack float32  `json:"charge_slack"`
+	Checks      []*Check `json:"checks"`
+	//Path is the list of decisions that led to the final StatusType
+	Path []string `json:"path"`
+}
+
+// studentValues returns the student values that are evaluated by rules
+func (r *RuleSet) studentValues(s *Student, devices []int, charges []*Charge) map[string]interface{} {
+	t2e2 := T2E2Missing
+	if s.T2E2Status != nil {
+		t2e2 = *(s.T2E2Status)
+	}
+
+	open := 0
+	for _, c := range charges {
+		if r.chargeOpen(c) {
+			open++
+		}
+	}
+
+	return map[string]interface{}{
+		"t2e2":                       t2e2,
+		"grade":                      Grade(s.Grade),
+		"campus":                     s.Campus,
+		"economically_disadvantaged": s.EconomicallyDisadvantaged,
+		"devices":                    len(devices),
+		"open_charges":               open,
+	}
+}
+
+// chargeValues returns the charge values that are evaluated by rules
+func (r *RuleSet) chargeValues(c *Charge) map[string]interface{} {
+	var ratio float32
+	if c.AmountCharged() != 0 {
+		ratio = c.AmountPaid / c.AmountCharged()
+	}
+
+	return map[string]interface{}{
+		"description": c.Description(),
+		"charged":     c.AmountCharged(),
+		"paid":        c.AmountPaid,
+		"balance":     c.AmountCharged() - c.AmountPaid,
+		"paid_ratio":  ratio,
+		"open":        r.chargeOpen(c),
+	}
+}
+
+// limit limits status to t, returning true if status was changed
+func limit(status *Status, t StatusType) bool {
+	if t != "" && statusRank(t) < statusRank(status.Type) {
+		status.Type = t
+		return true
+	}
+	return false
+}
+
+// apply limits status by rule and adds issue. If e is non-nil, the decision is added to its Path
+func (rule *Rule) apply(status *Status, issue *Issue, e *Explanation, subject string) {
+	limited := limit(status, rule.Status)
+	if issue.Description != "" {
+		status.Issues = append(status.Issues, issue)
+	}
+
+	if e == nil {
+		return
+	}
+
+	switch {
+	case limited:
+		e.Path = append(e.Path, fmt.Sprintf("Rule %s failed for %s: status limited to %s", rule.Name, subject, rule.Status))
+	case rule.Status != "":
+		e.Path = append(e.Path, fmt.Sprintf("Rule %s failed for %s: status already %s or lower", rule.Name, subject, status.Type))
+	default:
+		e.Path = append(e.Path, fmt.Sprintf("Rule %s failed for %s: issue added", rule.Name, subject))
+	}
+}
+
+// Evaluate returns the Status of the given student with the given checked out devices and charges
+func (r *RuleSet) Evaluate(s *Student, devices []int, charges []*Charge) *Status {
+	return r.evaluate(s, devices, charges, nil)
+}
+
+// Explain returns an Explanation of the Status of the given student with the given checked out devices and charges
+func (r *RuleSet) Explain(s *Student, devices []int, charges []*Charge) *Explanation {
+	e := &Explanation{ChargeSlack: r.ChargeSlack, Checks: make([]*Check, 0), Path: make([]string, 0)}
+	e.Status = r.evaluate(s, devices, charges, e)
+	return e
+}
+
+// evaluate returns the Status of the given student with the given checked out devices and charges.
+// If e is non-nil, every check and decision is recorded in it
+func (r *RuleSet) evaluate(s *Student, devices []int, charges []*Charge, e *Explanation) *Status {
+	status := &Status{Type: r.DefaultStatus, Issues: make([]*Issue, 0)}
+
+	var values map[string]interface{}
+	if e != nil {
+		values = r.studentValues(s, devices, charges)
+		e.Path = append(e.Path, fmt.Sprintf("Default status is %s", r.DefaultStatus))
+	}
+
+	check := func(rule *Rule, id int, passed bool, v map[string]interface{}) {
+		if e == nil {
+			return
+		}
+		subject := rule.Subject
+		if subject == "" {
+			subject = RuleSubjectStudent
+		}
+		e.Checks = append(e.Checks, &Check{
+			Name:       rule.Name,
+			Type:       CheckTypeRule,
+			Subject:    subject,
+			SubjectID:  id,
+			Passed:     passed,
+			Status:     rule.Status,
+			Issue:      rule.Issue,
+			Values:     v,
+			Thresholds: rule.Match,
+		})
+	}
+
+	for _, rule := range r.Rules {
+		if !r.matchStudent(&(rule.Match), s, devices, charges) {
+			check(rule, 0, true, values)
+			continue
+		}
+
+		switch rule.Subject {
+		case RuleSubjectDevice:
+			if len(devices) == 0 {
+				check(rule, 0, true, values)
+			}
+			for _, d := range devices {
+				check(rule, d, false, values)
+				rule.apply(status, &Issue{
+					Description: rule.Issue,
+					Link:        DeviceURLBase + strconv.Itoa(d),
+					LinkType:    LinkTypeDevice,
+				}, e, fmt.Sprintf("device %d", d))
+			}
+		case RuleSubjectCharge:
+			if len(charges) == 0 {
+				check(rule, 0, true, values)
+			}
+			for _, c := range charges {
+				matched := r.matchCharge(&(rule.Match), c)
+				if e != nil {
+					check(rule, c.ID, !matched, r.chargeValues(c))
+				}
+				if !matched {
+					continue
+				}
+				rule.apply(status, &Issue{
+					Description:    rule.Issue,
+					Link:           ChargeURLBase + strconv.Itoa(c.ID),
+					LinkType:       LinkTypeCharge,
+					LinkValue:      c.AmountCharged() - c.AmountPaid,
+					LinkAdditional: c.Description(),
+				}, e, fmt.Sprintf("charge %d", c.ID))
+			}
+		default:
+			check(rule, 0, false, values)
+			rule.apply(status, &Issue{Description: rule.Issue}, e, "student")
+		}
+	}
+
+	for _, policy := range r.Policies {
+		matched := matchCampus(policy.Campuses, s.Campus) && matchGrade(policy.GradeMin, policy.GradeMax, s.Grade)
+
+		if e != nil {
+			e.Checks = append(e.Checks, &Check{
+				Name:    policy.Name,
+				Type:    CheckTypePolicy,
+				Subject: RuleSubjectStudent,
+				Passed:  !matched,
+				Status:  policy.MaxStatus,
+				Issue:   policy.Issue,
+				Values:  values,
+				Thresholds: &struct {
+					Campuses []string `json:"campuses,omitempty"`
+					GradeMin *Grade   `json:"grade_min,omitempty"`
+					GradeMax *Grade   `json:"grade_max,omitempty"`
+				}{policy.Campuses, policy.GradeMin, policy.GradeMax},
+			})
+		}
+
+		if !matched {
+			continue
+		}
+
+		if limit(status, policy.MaxStatus) {
+			status.Issues = append(status.Issues, &Issue{Description: policy.Issue})
+			if e != nil {
+				e.Path = append(e.Path, fmt.Sprintf("Policy %s applies: status capped at %s", policy.Name, policy.MaxStatus))
+			}
+		} else if e != nil {
+			e.Path = append(e.Path, fmt.Sprintf("Policy %s applies: status already %s or lower", policy.Name, status.Type))
+		}
+	}
+
+	if e != nil {
+		e.Path = append(e.Path, fmt.Sprintf("Final status is %s", status.Type))
+	}
+
+	return status
+}
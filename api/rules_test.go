@@ -0,0 +1,205 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// baselineStatus is the Status computation used before RuleSets, which DefaultRuleSet must match
+func baselineStatus(s *Student, devices []int, charges []*Charge) *Status {
+	status := &Status{Issues: make([]*Issue, 0)}
+
+	if s.T2E2Status == nil {
+		status.Type = StatusTypeNone
+		status.Issues = append(status.Issues, &Issue{Description: "T2E2 Agreement not completed"})
+	}
+
+	if len(devices) > 0 {
+		status.Type = StatusTypeNone
+		for _, d := range devices {
+			status.Issues = append(status.Issues, &Issue{
+				Description: "Student has device checked out",
+				Link:        DeviceURLBase + strconv.Itoa(d),
+				LinkType:    LinkTypeDevice,
+			})
+		}
+	}
+
+	var noneCharges []*Charge
+	var redCharges []*Charge
+
+	for _, c := range charges {
+		if math.Abs(float64(c.AmountCharged()-c.AmountPaid)) < 1 {
+			continue
+		} else if c.AmountPaid >= (c.AmountCharged()/2)-1 {
+			redCharges = append(redCharges, c)
+		} else if c.AmountCharged()-c.AmountPaid-1 > 0 {
+			noneCharges = append(noneCharges, c)
+		}
+	}
+
+	if len(noneCharges) == 0 && len(redCharges) == 0 {
+		if s.T2E2Status != nil && *(s.T2E2Status) == "No" {
+			if status.Type != StatusTypeNone {
+				status.Type = StatusTypeRedBag
+			}
+			status.Issues = append(status.Issues, &Issue{
+				Description: "T2E2 Agreement does not permit student to take home device",
+			})
+		} else if status.Type != StatusTypeNone {
+			status.Type = StatusTypeBlackBag
+		}
+		return status
+	}
+
+	if len(noneCharges) == 0 && status.Type != StatusTypeNone {
+		status.Type = StatusTypeRedBag
+	} else {
+		status.Type = StatusTypeNone
+	}
+
+	for _, c := range noneCharges {
+		status.Issues = append(status.Issues, &Issue{
+			Description:    "Student has charge with less than 50% paid",
+			Link:           ChargeURLBase + strconv.Itoa(c.ID),
+			LinkType:       LinkTypeCharge,
+			LinkValue:      c.AmountCharged() - c.AmountPaid,
+			LinkAdditional: c.Description(),
+		})
+	}
+
+	for _, c := range redCharges {
+		status.Issues = append(status.Issues, &Issue{
+			Description:    "Student has unpaid charge",
+			Link:           ChargeURLBase + strconv.Itoa(c.ID),
+			LinkType:       LinkTypeCharge,
+			LinkValue:      c.AmountCharged() - c.AmountPaid,
+			LinkAdditional: c.Description(),
+		})
+	}
+
+	return status
+}
+
+func TestDefaultRuleSetMatchesBaseline(t *testing.T) {
+	yes, no, other := "Yes", "No", "Pending"
+	t2e2s := []*string{nil, &yes, &no, &other}
+
+	deviceLists := [][]int{nil, {1}, {1, 2}}
+
+	charge := func(id int, paid float32, charges string) *Charge {
+		return &Charge{ID: id, AmountPaid: paid, charges: charges}
+	}
+	chargeLists := [][]*Charge{
+		nil,
+		//paid, and paid within the $1 slack
+		{charge(1, 50, "Screen: 50.00")},
+		{charge(1, 49.5, "Screen: 50.00")},
+		//half paid, and within $1 of half paid
+		{charge(1, 25, "Screen: 50.00")},
+		{charge(1, 24.25, "Screen: 50.00")},
+		//less than half paid
+		{charge(1, 0, "Screen: 50.00")},
+		{charge(1, 23.5, "Screen: 30.00|Keyboard: 20.00")},
+		//small charges within the slack of half
+		{charge(1, 0, "Key: 1.50")},
+		{charge(1, 0, "Key: 2.50")},
+		//mixed
+		{charge(1, 0, "Screen: 50.00"), charge(2, 30, "Screen: 50.00"), charge(3, 50, "Screen: 50.00")},
+		{charge(1, 40, "Screen: 50.00"), charge(2, 10, "Keyboard: 20.00")},
+	}
+
+	rules := DefaultRuleSet()
+
+	for _, t2e2 := range t2e2s {
+		for _, devices := range deviceLists {
+			for i, charges := range chargeLists {
+				s := &Student{FirstName: "Jane", LastName: "Doe", OtherID: "100001", Grade: 9, T2E2Status: t2e2}
+				name := fmt.Sprintf("t2e2=%v/devices=%d/charges=%d", deref(t2e2), len(devices), i)
+
+				want := baselineStatus(s, devices, charges)
+				got := rules.Evaluate(s, devices, charges)
+
+				if got.Type != want.Type || !reflect.DeepEqual(got.Issues, want.Issues) {
+					t.Errorf("%s: Evaluate = %s %s, baseline = %s %s", name, got.Type, issueList(got.Issues), want.Type, issueList(want.Issues))
+				}
+			}
+		}
+	}
+}
+
+// issueList returns the descriptions and links of issues for test failures
+func issueList(issues []*Issue) string {
+	var list []string
+	for _, i := range issues {
+		list = append(list, fmt.Sprintf("%q %s %.2f", i.Description, i.Link, i.LinkValue))
+	}
+	return fmt.Sprint(list)
+}
+
+func TestDefaultRuleSetElementary(t *testing.T) {
+	yes := "Yes"
+	rules := DefaultRuleSet()
+
+	for _, grade := range []int{int(GradePreKindergarten), int(GradeKindergarten), 3, 5} {
+		s := &Student{FirstName: "Jane", LastName: "Doe", OtherID: "100001", Grade: grade, T2E2Status: &yes}
+		if status := rules.Evaluate(s, nil, nil); status.Type != StatusTypeClassroomOnly {
+			t.Errorf("grade %d: Evaluate = %s, want %s", grade, status.Type, StatusTypeClassroomOnly)
+		}
+	}
+
+	s := &Student{FirstName: "Jane", LastName: "Doe", OtherID: "100001", Grade: 6, T2E2Status: &yes}
+	if status := rules.Evaluate(s, nil, nil); status.Type != StatusTypeBlackBag {
+		t.Errorf("grade 6: Evaluate = %s, want %s", status.Type, StatusTypeBlackBag)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	no := "No"
+	s := &Student{FirstName: "Jane", LastName: "Doe", OtherID: "100001", Grade: 9, T2E2Status: &no}
+	devices := []int{7}
+	charges := []*Charge{{ID: 3, AmountPaid: 30, charges: "Screen: 50.00"}, {ID: 4, AmountPaid: 20, charges: "Keyboard: 20.00"}}
+
+	rules := DefaultRuleSet()
+	e := rules.Explain(s, devices, charges)
+
+	if !reflect.DeepEqual(e.Status, rules.Evaluate(s, devices, charges)) {
+		t.Errorf("Explain status = %+v, want Evaluate's %+v", e.Status, rules.Evaluate(s, devices, charges))
+	}
+	if e.ChargeSlack != rules.ChargeSlack || len(e.Path) == 0 {
+		t.Errorf("Explain = %+v, want the charge slack and a decision path", e)
+	}
+
+	type result struct {
+		subjectID int
+		passed    bool
+	}
+	got := make(map[string][]result)
+	for _, c := range e.Checks {
+		got[c.Name] = append(got[c.Name], result{c.SubjectID, c.Passed})
+	}
+
+	want := map[string][]result{
+		"t2e2_missing":               {{0, true}},
+		"device_checked_out":         {{7, false}},
+		"charge_less_than_half_paid": {{3, true}, {4, true}},
+		"charge_unpaid":              {{3, false}, {4, true}},
+		//the open charge keeps the T2E2 rule from matching
+		"t2e2_no_take_home":         {{0, true}},
+		"elementary_classroom_only": {{0, true}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Explain checks = %+v, want %+v", got, want)
+	}
+
+	for _, c := range e.Checks {
+		if c.Subject == RuleSubjectCharge && c.SubjectID == 3 {
+			if c.Values["charged"] != float32(50) || c.Values["paid"] != float32(30) {
+				t.Errorf("charge check values = %+v, want charged 50 and paid 30", c.Values)
+			}
+		}
+	}
+}
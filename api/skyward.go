@@ -0,0 +1,253 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// SkywardSource is a StudentSource that queries Skyward's PUB schema.
+// Queries use the Skyward transaction in the context if there is one, otherwise a new transaction is started on DB
+type SkywardSource struct {
+	DB *sql.DB
+}
+
+// withTx calls f with the Skyward transaction for ctx
+func (s *SkywardSource) withTx(ctx context.Context, f func(tx *sql.Tx) error) error {
+	if tx, ok := ctx.Value(SkywardTransactionKey).(*sql.Tx); ok {
+		return f(tx)
+	}
+
+	if s.DB == nil {
+		return &Error{Description: "No Skyward transaction or database"}
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return &Error{Description: "Could not begin Skyward transaction", Err: err}
+	}
+	defer tx.Rollback()
+
+	return f(tx)
+}
+
+// GetStudent returns the Student with the given otherID
+func (s *SkywardSource) GetStudent(ctx context.Context, otherID string) (*Student, error) {
+	var stu *Student
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		stu, err = queryStudent(tx, otherID)
+		return err
+	})
+	return stu, err
+}
+
+// GetStudentList returns a list of all Students
+func (s *SkywardSource) GetStudentList(ctx context.Context) ([]*Student, error) {
+	var students []*Student
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		students, err = queryStudentList(tx)
+		return err
+	})
+	return students, err
+}
+
+// queryStudent returns the Student with the given otherID from Skyward
+func queryStudent(tx *sql.Tx, otherID string) (*Student, error) {
+	s := &Student{}
+
+	var (
+		firstName *string
+		lastName  *string
+	)
+
+	err := tx.QueryRow(`
+	SELECT
+		name."FIRST-NAME" AS First_Name,
+		name."LAST-NAME" AS Last_Name,
+		student."OTHER-ID" AS Other_I_D,
+		(12 - (student."GRAD-YR" - entity."SCHOOL-YEAR")) AS Grade,
+		data."STATUS" AS Status,
+        CASE
+            WHEN eco."ECO-DIS-CODE" = '00' THEN CAST(0 AS BIT)
+            WHEN eco."ECO-DIS-CODE" IS NULL THEN CAST(0 AS BIT)
+            ELSE CAST(1 AS BIT)
+        END AS Economically_Disadvantaged,
+		entity."ENTITY-ID" AS Entity_I_D
+	FROM PUB.NAME AS name
+	INNER JOIN PUB."STUDENT" AS student ON
+		name."NAME-ID" = student."NAME-ID"
+
+	INNER JOIN PUB."STUDENT-ENTITY" as sentity ON
+		sentity."STUDENT-ID" = student."STUDENT-ID"
+
+	INNER JOIN PUB."ENTITY" as entity ON
+		entity."ENTITY-ID" = sentity."ENTITY-ID"
+
+    LEFT JOIN (
+		SELECT
+			data."QUDDAT-SRC-ID" AS "STUDENT-ID",
+			data."QUDDAT-CHAR" AS "STATUS"
+
+		FROM PUB."QUDDAT-DATA" AS data
+
+		INNER JOIN PUB."QUDTBL-TABLES" AS tables ON
+			data."QUDDAT-STORAGE-TYPE" = 'Custom Student' AND
+			data."QUDTBL-TABLE-ID" = tables."QUDTBL-TABLE-ID" AND
+			tables."QUDTBL-DESC" = 'T2E2'
+
+		INNER JOIN PUB."QUDFLD-FIELDS" AS fields ON
+			data."QUDFLD-FIELD-ID" = fields."QUDFLD-FIELD-ID" AND
+			fields."QUDFLD-FIELD-LABEL" = 'Can_Take_Chromebook_Home'
+    ) AS data ON
+		student."STUDENT-ID" = data."STUDENT-ID"
+
+    LEFT JOIN (
+        SELECT
+            tran."NAME-ID",
+            code."FS-LUN-CODE-STATE" AS "ECO-DIS-CODE"
+
+        FROM PUB."FS-TRANSACTION" AS tran
+
+        INNER JOIN (
+            SELECT "NAME-ID", MAX("FS-TRAN-EFFECTIVE-DATE") AS "FS-TRAN-EFFECTIVE-DATE"
+            FROM PUB."FS-TRANSACTION"
+            GROUP BY "NAME-ID"
+        ) AS filter ON
+                filter."NAME-ID" = tran."NAME-ID" AND
+                filter."FS-TRAN-EFFECTIVE-DATE" = tran."FS-TRAN-EFFECTIVE-DATE"
+
+        INNER JOIN PUB."FS-LUN-CODE" AS code ON
+            code."FS-LUN-CODE-ID" = tran."FS-LUN-CODE-ID"
+    ) AS eco ON
+        name."NAME-ID" = eco."NAME-ID"
+
+	WHERE sentity."STUDENT-STATUS" = 'A' AND
+	student."GRAD-YR" >= entity."SCHOOL-YEAR" AND
+	(student."GRAD-YR" - entity."SCHOOL-YEAR") <= 13 AND
+	student."OTHER-ID" = ?
+
+	WITH (NOLOCK)
+	`, otherID).Scan(
+		&(firstName),
+		&(lastName),
+		&(s.OtherID),
+		&(s.Grade),
+		&(s.T2E2Status),
+		&(s.EconomicallyDisadvantaged),
+		&(s.Campus),
+	)
+
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, &Error{Description: fmt.Sprintf("Student could not be found with OtherID: %s", otherID), Err: err, RequestError: true}
+	case err != nil:
+		return nil, &Error{Description: fmt.Sprintf("Could not query Student(%s)", otherID), Err: err}
+	}
+
+	s.FirstName = formalizeName(*firstName)
+	s.LastName = formalizeName(*lastName)
+
+	return s, nil
+}
+
+// queryStudentList returns a list of all Students from Skyward
+func queryStudentList(tx *sql.Tx) ([]*Student, error) {
+	rows, err := tx.Query(`
+	SELECT
+		name."FIRST-NAME" AS First_Name,
+		name."LAST-NAME" AS Last_Name,
+		student."OTHER-ID" AS Other_I_D,
+		(12 - (student."GRAD-YR" - entity."SCHOOL-YEAR")) AS Grade,
+		data."STATUS" AS Status,
+        CASE
+            WHEN eco."ECO-DIS-CODE" = '00' THEN CAST(0 AS BIT)
+            WHEN eco."ECO-DIS-CODE" IS NULL THEN CAST(0 AS BIT)
+            ELSE CAST(1 AS BIT)
+        END AS Economically_Disadvantaged,
+		entity."ENTITY-ID" AS Entity_I_D
+	FROM PUB.NAME AS name
+	INNER JOIN PUB."STUDENT" AS student ON
+		name."NAME-ID" = student."NAME-ID"
+
+	INNER JOIN PUB."STUDENT-ENTITY" as sentity ON
+		sentity."STUDENT-ID" = student."STUDENT-ID"
+
+	INNER JOIN PUB."ENTITY" as entity ON
+		entity."ENTITY-ID" = sentity."ENTITY-ID"
+
+    LEFT JOIN (
+		SELECT
+			data."QUDDAT-SRC-ID" AS "STUDENT-ID",
+			data."QUDDAT-CHAR" AS "STATUS"
+
+		FROM PUB."QUDDAT-DATA" AS data
+
+		INNER JOIN PUB."QUDTBL-TABLES" AS tables ON
+			data."QUDDAT-STORAGE-TYPE" = 'Custom Student' AND
+			data."QUDTBL-TABLE-ID" = tables."QUDTBL-TABLE-ID" AND
+			tables."QUDTBL-DESC" = 'T2E2'
+
+		INNER JOIN PUB."QUDFLD-FIELDS" AS fields ON
+			data."QUDFLD-FIELD-ID" = fields."QUDFLD-FIELD-ID" AND
+			fields."QUDFLD-FIELD-LABEL" = 'Can_Take_Chromebook_Home'
+    ) AS data ON
+		student."STUDENT-ID" = data."STUDENT-ID"
+
+    LEFT JOIN (
+        SELECT
+            tran."NAME-ID",
+            code."FS-LUN-CODE-STATE" AS "ECO-DIS-CODE"
+
+        FROM PUB."FS-TRANSACTION" AS tran
+
+        INNER JOIN (
+            SELECT "NAME-ID", MAX("FS-TRAN-EFFECTIVE-DATE") AS "FS-TRAN-EFFECTIVE-DATE"
+            FROM PUB."FS-TRANSACTION"
+            GROUP BY "NAME-ID"
+        ) AS filter ON
+                filter."NAME-ID" = tran."NAME-ID" AND
+                filter."FS-TRAN-EFFECTIVE-DATE" = tran."FS-TRAN-EFFECTIVE-DATE"
+
+        INNER JOIN PUB."FS-LUN-CODE" AS code ON
+            code."FS-LUN-CODE-ID" = tran."FS-LUN-CODE-ID"
+    ) AS eco ON
+        name."NAME-ID" = eco."NAME-ID"
+
+	WHERE sentity."STUDENT-STATUS" = 'A' AND
+	student."GRAD-YR" >= entity."SCHOOL-YEAR" AND
+	(student."GRAD-YR" - entity."SCHOOL-YEAR") <= 13
+
+	WITH (NOLOCK)
+	`)
+	if err != nil {
+		return nil, &Error{Description: "Could not query Student list", Err: err}
+	}
+	defer rows.Close()
+
+	var students []*Student
+
+	var (
+		firstName *string
+		lastName  *string
+	)
+
+	for rows.Next() {
+		s := new(Student)
+		if err := rows.Scan(&(firstName), &(lastName), &(s.OtherID), &(s.Grade), &(s.T2E2Status), &(s.EconomicallyDisadvantaged), &(s.Campus)); err != nil {
+			return nil, &Error{Description: "Could not scan Student row", Err: err}
+		}
+
+		s.FirstName = formalizeName(*firstName)
+		s.LastName = formalizeName(*lastName)
+
+		students = append(students, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &Error{Description: "Could not scan Student rows", Err: err}
+	}
+
+	return students, nil
+}
@@ -0,0 +1,686 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// sqlRepository implements the repositories with SQL that is shared between databases
+type sqlRepository struct {
+	//appendNotes is the SQL expression that appends a parameter to the Notes column
+	appendNotes string
+	//matchUser is the SQL condition that matches the User column to a nameKey parameter using an index
+	matchUser string
+}
+
+// MySQLRepository implements the repositories for a MySQL inventory database
+type MySQLRepository struct {
+	sqlRepository
+}
+
+// NewMySQLRepository returns a new MySQLRepository
+func NewMySQLRepository() *MySQLRepository {
+	//the User column's case insensitive collation ignores case and trailing spaces
+	return &MySQLRepository{sqlRepository{appendNotes: "CONCAT(COALESCE(Notes, ''), ?)", matchUser: "User = ?"}}
+}
+
+// SQLiteRepository implements the repositories for a SQLite inventory database
+type SQLiteRepository struct {
+	sqlRepository
+}
+
+// NewSQLiteRepository returns a new SQLiteRepository
+func NewSQLiteRepository() *SQLiteRepository {
+	//the expression matches the devices_user and charges_user indexes
+	return &SQLiteRepository{sqlRepository{appendNotes: "COALESCE(Notes, '') || ?", matchUser: "LOWER(RTRIM(User)) = ?"}}
+}
+
+// deref returns the value of s, or an empty string if s is nil
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// queryDevices returns the Devices matching the given SQL condition and args.
+// desc is used to describe the query in errors
+func (r *sqlRepository) queryDevices(ctx context.Context, desc, condition string, args ...interface{}) ([]*Device, error) {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	rows, err := tx.Query(`
+	SELECT id, Inventory_Number, Serial_Number, Bag_Tag, Status, User, device_type, manufacturer, model, Campus, Room, Notes
+	FROM devices WHERE `+condition+`;`, args...)
+	if err != nil {
+		return nil, &Error{Description: fmt.Sprintf("Could not query Device(%s)", desc), Err: err}
+	}
+	defer rows.Close()
+
+	var devices []*Device
+
+	for rows.Next() {
+		var (
+			d                                                            = new(Device)
+			inventoryNumber, serialNumber, tag, status, user, deviceType *string
+			manufacturer, model, campus, room, notes                     *string
+		)
+
+		if err := rows.Scan(
+			&(d.ID), &inventoryNumber, &serialNumber, &tag, &status, &user, &deviceType, &manufacturer, &model, &campus, &room, &notes,
+		); err != nil {
+			return nil, &Error{Description: fmt.Sprintf("Could not scan Device(%s)", desc), Err: err}
+		}
+
+		d.InventoryNumber = deref(inventoryNumber)
+		d.SerialNumber = deref(serialNumber)
+		d.BagTag = deref(tag)
+		d.Status = deref(status)
+		d.User = deref(user)
+		d.DeviceType = deref(deviceType)
+		d.Manufacturer = deref(manufacturer)
+		d.Model = deref(model)
+		d.Campus = deref(campus)
+		d.Room = deref(room)
+		d.Notes = deref(notes)
+
+		devices = append(devices, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &Error{Description: fmt.Sprintf("Could not scan Device(%s)", desc), Err: err}
+	}
+
+	return devices, nil
+}
+
+// getDeviceBy returns the Device whose column matches value, or nil if it doesn't exist.
+// name is used to describe column in errors. If more than one device matches, a request error is returned
+func (r *sqlRepository) getDeviceBy(ctx context.Context, column, name, value string) (*Device, error) {
+	devices, err := r.queryDevices(ctx, name+" "+value, column+" = ? ORDER BY id LIMIT 2", value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(devices) {
+	case 0:
+		return nil, nil
+	case 1:
+		return devices[0], nil
+	default:
+		return nil, &Error{Description: fmt.Sprintf("%s %s matches more than one device", name, value), Err: nil, RequestError: true}
+	}
+}
+
+// GetDevice returns the Device with the given bagTag, or nil if it doesn't exist
+func (r *sqlRepository) GetDevice(ctx context.Context, bagTag string) (*Device, error) {
+	return r.getDeviceBy(ctx, "Bag_Tag", "Bag Tag", bagTag)
+}
+
+// GetDeviceBySerialNumber returns the Device with the given serialNumber, or nil if it doesn't exist
+func (r *sqlRepository) GetDeviceBySerialNumber(ctx context.Context, serialNumber string) (*Device, error) {
+	return r.getDeviceBy(ctx, "Serial_Number", "Serial Number", serialNumber)
+}
+
+// GetDeviceByInventoryNumber returns the Device with the given inventoryNumber, or nil if it doesn't exist
+func (r *sqlRepository) GetDeviceByInventoryNumber(ctx context.Context, inventoryNumber string) (*Device, error) {
+	return r.getDeviceBy(ctx, "Inventory_Number", "Inventory Number", inventoryNumber)
+}
+
+// GetDevices returns the devices assigned to the user with the given name. Names are matched with nameKey
+func (r *sqlRepository) GetDevices(ctx context.Context, name string) ([]*Device, error) {
+	return r.queryDevices(ctx, "User "+name, r.matchUser+" ORDER BY id", nameKey(name))
+}
+
+// GetDeviceIDs returns the ids of the devices assigned to the user with the given name. Names are matched with nameKey
+func (r *sqlRepository) GetDeviceIDs(ctx context.Context, name string) ([]int, error) {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	rows, err := tx.Query(`SELECT id FROM devices WHERE `+r.matchUser+`;`, nameKey(name))
+	if err != nil {
+		return nil, &Error{Description: "Could not query Device list", Err: err}
+	}
+	defer rows.Close()
+
+	var devices []int
+
+	for rows.Next() {
+		var d *int
+		if err := rows.Scan(&(d)); err != nil {
+			return nil, &Error{Description: "Could not scan Device row", Err: err}
+		}
+
+		devices = append(devices, *d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &Error{Description: "Could not scan Device rows", Err: err}
+	}
+
+	return devices, nil
+}
+
+// GetDeviceIDMap returns the ids of all devices assigned to a user, keyed by nameKey of the user
+func (r *sqlRepository) GetDeviceIDMap(ctx context.Context) (map[string][]int, error) {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	rows, err := tx.Query(`SELECT id, user FROM devices WHERE user IS NOT NULL AND user != '';`)
+	if err != nil {
+		return nil, &Error{Description: "Could not query Device list", Err: err}
+	}
+	defer rows.Close()
+
+	devices := make(map[string][]int)
+
+	for rows.Next() {
+		var (
+			d    int
+			user string
+		)
+		if err := rows.Scan(&d, &user); err != nil {
+			return nil, &Error{Description: "Could not scan Device row", Err: err}
+		}
+
+		devices[nameKey(user)] = append(devices[nameKey(user)], d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &Error{Description: "Could not scan Device rows", Err: err}
+	}
+
+	return devices, nil
+}
+
+// AssignDevice sets the User of the device in Storage with the given bagTag to name and the Status to Checked Out
+func (r *sqlRepository) AssignDevice(ctx context.Context, bagTag, name, note string) (bool, error) {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	res, err := tx.Exec(`
+	UPDATE devices SET User = ?, Status = 'Checked Out', Notes = `+r.appendNotes+`
+	WHERE bag_tag = ? AND Status = 'Storage';
+	`, name, note, bagTag)
+
+	if err != nil {
+		return false, &Error{Description: fmt.Sprintf("Could not update Device(%s)", bagTag), Err: err}
+	}
+
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// UnassignDevice clears the User of the device with the given bagTag that is Checked Out to name. Names are matched with nameKey
+func (r *sqlRepository) UnassignDevice(ctx context.Context, bagTag, name, status, note string) (bool, error) {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	res, err := tx.Exec(`
+	UPDATE devices SET User = '', Status = ?, Notes = `+r.appendNotes+`
+	WHERE bag_tag = ? AND Status = 'Checked Out' AND `+r.matchUser+`;
+	`, status, note, bagTag, nameKey(name))
+
+	if err != nil {
+		return false, &Error{Description: fmt.Sprintf("Could not update Device(%s)", bagTag), Err: err}
+	}
+
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// RestoreDevice sets the User, Status, and Notes of the device with the given id if it is still Checked Out to name.
+// Names are matched with nameKey
+func (r *sqlRepository) RestoreDevice(ctx context.Context, id int, name, user, status, notes string) (bool, error) {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	res, err := tx.Exec(`
+	UPDATE devices SET User = ?, Status = ?, Notes = ?
+	WHERE id = ? AND `+r.matchUser+` AND Status = 'Checked Out';
+	`, user, status, notes, id, nameKey(name))
+
+	if err != nil {
+		return false, &Error{Description: fmt.Sprintf("Could not update Device(%d)", id), Err: err}
+	}
+
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// VerifyDevice records a verification of the device with the given bagTag by username at date
+func (r *sqlRepository) VerifyDevice(ctx context.Context, bagTag, username string, date time.Time) (int64, error) {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	res, err := tx.Exec(`
+	INSERT INTO verifications(device_id, username, date) 
+	SELECT id, ?, ? FROM devices WHERE bag_tag = ?;`,
+		username, date, bagTag)
+	if err != nil {
+		return 0, &Error{Description: fmt.Sprintf("Could not verify Device(%s)", bagTag), Err: err}
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, &Error{Description: fmt.Sprintf("Could not get verification id for Device(%s)", bagTag), Err: err}
+	}
+
+	return id, nil
+}
+
+// DeleteVerification deletes the verification with the given id
+func (r *sqlRepository) DeleteVerification(ctx context.Context, id int64) error {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	if _, err := tx.Exec("DELETE FROM verifications WHERE id = ?;", id); err != nil {
+		return &Error{Description: fmt.Sprintf("Could not delete Verification(%d)", id), Err: err}
+	}
+
+	return nil
+}
+
+// GetVerifications returns the verifications of the device with the given id, oldest first
+func (r *sqlRepository) GetVerifications(ctx context.Context, deviceID int) ([]*Verification, error) {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	rows, err := tx.Query("SELECT id, username, date FROM verifications WHERE device_id = ? ORDER BY date, id;", deviceID)
+	if err != nil {
+		return nil, &Error{Description: fmt.Sprintf("Could not query Verification list for Device(%d)", deviceID), Err: err}
+	}
+	defer rows.Close()
+
+	var verifications []*Verification
+
+	for rows.Next() {
+		v := &Verification{DeviceID: deviceID}
+		if err := rows.Scan(&(v.ID), &(v.Username), &(v.Date)); err != nil {
+			return nil, &Error{Description: "Could not scan Verification row", Err: err}
+		}
+
+		verifications = append(verifications, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &Error{Description: "Could not scan Verification rows", Err: err}
+	}
+
+	return verifications, nil
+}
+
+// queryCharges returns the Charges matching the given SQL condition and args.
+// Charges without an amount paid are treated as having nothing paid
+func (r *sqlRepository) queryCharges(ctx context.Context, condition string, args ...interface{}) ([]*Charge, error) {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	rows, err := tx.Query(`SELECT id, user, amount_paid, charges FROM charges WHERE `+condition+`;`, args...)
+	if err != nil {
+		return nil, &Error{Description: "Could not query Charge list", Err: err}
+	}
+	defer rows.Close()
+
+	var charges []*Charge
+
+	for rows.Next() {
+		var (
+			c          = new(Charge)
+			user       sql.NullString
+			amountPaid sql.NullFloat64
+			items      sql.NullString
+		)
+		if err := rows.Scan(&(c.ID), &user, &amountPaid, &items); err != nil {
+			return nil, &Error{Description: "Could not scan Charge row", Err: err}
+		}
+
+		c.User = user.String
+		c.AmountPaid = float32(amountPaid.Float64)
+		c.charges = items.String
+
+		charges = append(charges, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &Error{Description: "Could not scan Charge rows", Err: err}
+	}
+
+	return charges, nil
+}
+
+// GetCharges returns the charges for the user with the given name. Names are matched with nameKey
+func (r *sqlRepository) GetCharges(ctx context.Context, name string) ([]*Charge, error) {
+	return r.queryCharges(ctx, r.matchUser, nameKey(name))
+}
+
+// GetChargeMap returns all charges with a user, keyed by nameKey of the user
+func (r *sqlRepository) GetChargeMap(ctx context.Context) (map[string][]*Charge, error) {
+	list, err := r.queryCharges(ctx, "user IS NOT NULL")
+	if err != nil {
+		return nil, err
+	}
+
+	charges := make(map[string][]*Charge)
+	for _, c := range list {
+		charges[nameKey(c.User)] = append(charges[nameKey(c.User)], c)
+	}
+
+	return charges, nil
+}
+
+// CreateCharge creates a charge to the user with the given name for the device with the given bagTag
+func (r *sqlRepository) CreateCharge(ctx context.Context, bagTag, name, charges, note string) (bool, error) {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	res, err := tx.Exec(`
+	INSERT INTO charges(Inventory_Number, User, Amount_Paid, Charges, Notes)
+	SELECT Inventory_Number, ?, 0, ?, ? FROM devices WHERE bag_tag = ?;`,
+		name, charges, note, bagTag)
+	if err != nil {
+		return false, &Error{Description: fmt.Sprintf("Could not create Charge for Device(%s)", bagTag), Err: err}
+	}
+
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// GetCharge returns the Charge with the given id, or nil if it doesn't exist
+func (r *sqlRepository) GetCharge(ctx context.Context, id int) (*Charge, error) {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	var (
+		c          = &Charge{ID: id}
+		user       *string
+		amountPaid *float32
+		items      *string
+	)
+
+	err := tx.QueryRow(`SELECT user, amount_paid, charges FROM charges WHERE id = ?;`, id).Scan(&user, &amountPaid, &items)
+
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		return nil, &Error{Description: fmt.Sprintf("Could not query Charge(%d)", id), Err: err}
+	}
+
+	if amountPaid != nil {
+		c.AmountPaid = *amountPaid
+	}
+	c.User = deref(user)
+	c.charges = deref(items)
+
+	return c, nil
+}
+
+// AddPayment adds amount to the amount paid of the charge with the given id, appending note to the notes field,
+// if the new amount paid isn't more than charged
+func (r *sqlRepository) AddPayment(ctx context.Context, id int, amount, charged float32, note string) (bool, error) {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	//the balance is checked in the UPDATE so it's checked against the current amount paid
+	res, err := tx.Exec(`
+	UPDATE charges SET Amount_Paid = COALESCE(Amount_Paid, 0) + ?, Notes = `+r.appendNotes+`
+	WHERE id = ? AND COALESCE(Amount_Paid, 0) + ? <= ? + 0.005;`,
+		amount, note, id, amount, charged)
+	if err != nil {
+		return false, &Error{Description: fmt.Sprintf("Could not update Charge(%d)", id), Err: err}
+	}
+
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// GetLoanDeviceIDs returns the ids of the devices on loan to the student with the given otherID
+func (r *sqlRepository) GetLoanDeviceIDs(ctx context.Context, otherID string) (map[int]bool, error) {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	rows, err := tx.Query(`SELECT device_id FROM loans WHERE other_id=? AND returned IS NULL;`, otherID)
+	if err != nil {
+		return nil, &Error{Description: "Could not query Loan list", Err: err}
+	}
+	defer rows.Close()
+
+	devices := make(map[int]bool)
+
+	for rows.Next() {
+		var d int
+		if err := rows.Scan(&d); err != nil {
+			return nil, &Error{Description: "Could not scan Loan row", Err: err}
+		}
+
+		devices[d] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &Error{Description: "Could not scan Loan rows", Err: err}
+	}
+
+	return devices, nil
+}
+
+// CreateLoan records a loan of the device with the given bagTag to the student with the given otherID and name, due at due
+func (r *sqlRepository) CreateLoan(ctx context.Context, bagTag, otherID, name, username string, due time.Time) error {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	_, err := tx.Exec(`
+	INSERT INTO loans(device_id, other_id, user, username, checked_out, due)
+	SELECT id, ?, ?, ?, ?, ? FROM devices WHERE bag_tag = ?;`,
+		otherID, name, username, time.Now(), due, bagTag)
+	if err != nil {
+		return &Error{Description: fmt.Sprintf("Could not create Loan for Device(%s)", bagTag), Err: err}
+	}
+
+	return nil
+}
+
+// ReturnLoan marks any open loan for the device with the given bagTag as returned
+func (r *sqlRepository) ReturnLoan(ctx context.Context, bagTag string) error {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	_, err := tx.Exec(`
+	UPDATE loans SET returned = ?
+	WHERE returned IS NULL AND device_id IN (SELECT id FROM devices WHERE bag_tag = ?);`,
+		time.Now(), bagTag)
+	if err != nil {
+		return &Error{Description: fmt.Sprintf("Could not return Loan for Device(%s)", bagTag), Err: err}
+	}
+
+	return nil
+}
+
+// GetLoans returns the Loans that haven't been returned, soonest due first
+func (r *sqlRepository) GetLoans(ctx context.Context, overdue bool) ([]*Loan, error) {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	query := `
+	SELECT l.id, l.device_id, d.bag_tag, l.other_id, l.user, l.username, l.checked_out, l.due, l.returned
+	FROM loans AS l
+	INNER JOIN devices AS d ON d.id = l.device_id
+	WHERE l.returned IS NULL`
+	var args []interface{}
+
+	if overdue {
+		query += " AND l.due < ?"
+		args = append(args, time.Now())
+	}
+
+	rows, err := tx.Query(query+" ORDER BY l.due;", args...)
+	if err != nil {
+		return nil, &Error{Description: "Could not query Loan list", Err: err}
+	}
+	defer rows.Close()
+
+	var loans []*Loan
+
+	for rows.Next() {
+		l := new(Loan)
+		if err := rows.Scan(&(l.ID), &(l.DeviceID), &(l.BagTag), &(l.OtherID), &(l.Name), &(l.Username), &(l.CheckedOut), &(l.Due), &(l.Returned)); err != nil {
+			return nil, &Error{Description: "Could not scan Loan row", Err: err}
+		}
+
+		loans = append(loans, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &Error{Description: "Could not scan Loan rows", Err: err}
+	}
+
+	return loans, nil
+}
+
+// CreateCheckout records c, returning its id
+func (r *sqlRepository) CreateCheckout(ctx context.Context, c *Checkout) (int, error) {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	res, err := tx.Exec(`
+	INSERT INTO checkouts(device_id, verification_id, other_id, user, status_type, username, display_name, prev_user, prev_status, prev_notes, date)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		c.DeviceID, c.VerificationID, c.OtherID, c.Name, string(c.StatusType), c.Username, c.DisplayName,
+		c.PrevUser, c.PrevStatus, c.PrevNotes, c.Date,
+	)
+	if err != nil {
+		return 0, &Error{Description: fmt.Sprintf("Could not record Checkout of Device(%d)", c.DeviceID), Err: err}
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, &Error{Description: fmt.Sprintf("Could not get Checkout id for Device(%d)", c.DeviceID), Err: err}
+	}
+
+	return int(id), nil
+}
+
+// GetLastCheckout returns the most recent Checkout of the device with the given bagTag to the student with the given otherID
+// that hasn't been undone, or nil if it doesn't exist
+func (r *sqlRepository) GetLastCheckout(ctx context.Context, bagTag, otherID string) (*Checkout, error) {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	var (
+		c                               = &Checkout{OtherID: otherID}
+		statusType                      *string
+		prevUser, prevStatus, prevNotes *string
+	)
+
+	err := tx.QueryRow(`
+	SELECT c.id, c.device_id, c.verification_id, c.user, c.status_type, c.username, c.display_name, c.prev_user, c.prev_status, c.prev_notes, c.date
+	FROM checkouts AS c
+	INNER JOIN devices AS d ON d.id = c.device_id
+	WHERE d.bag_tag = ? AND c.other_id = ? AND c.undone IS NULL
+	ORDER BY c.date DESC, c.id DESC LIMIT 1;`,
+		bagTag, otherID,
+	).Scan(&(c.ID), &(c.DeviceID), &(c.VerificationID), &(c.Name), &statusType, &(c.Username), &(c.DisplayName), &prevUser, &prevStatus, &prevNotes, &(c.Date))
+
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		return nil, &Error{Description: fmt.Sprintf("Could not query Checkout of Device(%s)", bagTag), Err: err}
+	}
+
+	c.StatusType = StatusType(deref(statusType))
+	c.PrevUser = deref(prevUser)
+	c.PrevStatus = deref(prevStatus)
+	c.PrevNotes = deref(prevNotes)
+
+	return c, nil
+}
+
+// UndoCheckout marks the checkout with the given id as undone at date
+func (r *sqlRepository) UndoCheckout(ctx context.Context, id int, date time.Time) error {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	if _, err := tx.Exec("UPDATE checkouts SET undone = ? WHERE id = ?;", date, id); err != nil {
+		return &Error{Description: fmt.Sprintf("Could not update Checkout(%d)", id), Err: err}
+	}
+
+	return nil
+}
+
+// GetCheckoutReceipt returns the Receipt for the checkout with the given id, or nil if it doesn't exist
+func (r *sqlRepository) GetCheckoutReceipt(ctx context.Context, id int) (*Receipt, bool, error) {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	var (
+		rc                          = &Receipt{ID: id}
+		statusType                  string
+		undone                      *time.Time
+		bagTag, serialNumber, model *string
+	)
+
+	err := tx.QueryRow(`
+	SELECT c.other_id, c.user, c.status_type, c.display_name, c.date, c.undone, d.bag_tag, d.serial_number, d.model
+	FROM checkouts AS c
+	LEFT JOIN devices AS d ON d.id = c.device_id
+	WHERE c.id = ?;`, id).Scan(
+		&(rc.OtherID), &(rc.Name), &statusType, &(rc.DisplayName), &(rc.Date), &undone, &bagTag, &serialNumber, &model,
+	)
+
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, false, nil
+	case err != nil:
+		return nil, false, &Error{Description: fmt.Sprintf("Could not query Checkout(%d)", id), Err: err}
+	}
+
+	rc.StatusType = StatusType(statusType)
+	rc.BagTag = deref(bagTag)
+	rc.SerialNumber = deref(serialNumber)
+	rc.Model = deref(model)
+
+	return rc, undone != nil, nil
+}
+
+// CreatePayment records p, returning its id
+func (r *sqlRepository) CreatePayment(ctx context.Context, p *Payment) (int, error) {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	res, err := tx.Exec(`
+	INSERT INTO payments(charge_id, other_id, user, amount, method, receipt_number, status_type, username, display_name, date)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		p.ChargeID, p.OtherID, p.Name, p.Amount, p.Method, nullString(p.ReceiptNumber), string(p.StatusType), p.Username, p.DisplayName, p.Date,
+	)
+	if err != nil {
+		return 0, &Error{Description: fmt.Sprintf("Could not record Payment for Charge(%d)", p.ChargeID), Err: err}
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, &Error{Description: fmt.Sprintf("Could not get Payment id for Charge(%d)", p.ChargeID), Err: err}
+	}
+
+	return int(id), nil
+}
+
+// GetPaymentReceipt returns the Receipt for the payment with the given id, or nil if it doesn't exist
+func (r *sqlRepository) GetPaymentReceipt(ctx context.Context, id int) (*Receipt, error) {
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+
+	var (
+		rc                                 = &Receipt{ID: id}
+		receiptNumber, statusType, charges *string
+		bagTag, serialNumber, model        *string
+	)
+
+	err := tx.QueryRow(`
+	SELECT p.other_id, p.user, p.amount, p.method, p.receipt_number, p.status_type, p.display_name, p.date,
+		ch.charges, d.bag_tag, d.serial_number, d.model
+	FROM payments AS p
+	LEFT JOIN charges AS ch ON ch.id = p.charge_id
+	LEFT JOIN devices AS d ON d.Inventory_Number = ch.Inventory_Number
+	WHERE p.id = ?;`, id).Scan(
+		&(rc.OtherID), &(rc.Name), &(rc.Amount), &(rc.Method), &receiptNumber, &statusType, &(rc.DisplayName), &(rc.Date),
+		&charges, &bagTag, &serialNumber, &model,
+	)
+
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		return nil, &Error{Description: fmt.Sprintf("Could not query Payment(%d)", id), Err: err}
+	}
+
+	c := &Charge{charges: deref(charges)}
+	rc.Charges = c.Items()
+	rc.Charged = c.AmountCharged()
+	rc.ReceiptNumber = deref(receiptNumber)
+	rc.StatusType = StatusType(deref(statusType))
+	rc.BagTag = deref(bagTag)
+	rc.SerialNumber = deref(serialNumber)
+	rc.Model = deref(model)
+
+	return rc, nil
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/korylprince/bisd-device-checkout-server/migrations"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// testContext returns a context with an inventory transaction on a new, migrated, in-memory SQLite database
+// and a test user. The SQLite repositories are used for the duration of the test
+func testContext(tb testing.TB) context.Context {
+	tb.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		tb.Fatal("Could not open database:", err)
+	}
+	//each connection to :memory: is a separate database
+	db.SetMaxOpenConns(1)
+	tb.Cleanup(func() { db.Close() })
+
+	if _, err = migrations.Up(db, "sqlite3", 0); err != nil {
+		tb.Fatal("Could not migrate database:", err)
+	}
+
+	devices, charges, loans, checkouts, payments := Devices, Charges, Loans, Checkouts, Payments
+	repo := NewSQLiteRepository()
+	Devices, Charges, Loans, Checkouts, Payments = repo, repo, repo, repo, repo
+	tb.Cleanup(func() { Devices, Charges, Loans, Checkouts, Payments = devices, charges, loans, checkouts, payments })
+
+	tx, err := db.Begin()
+	if err != nil {
+		tb.Fatal("Could not begin transaction:", err)
+	}
+	tb.Cleanup(func() { _ = tx.Rollback() })
+
+	ctx := context.WithValue(context.Background(), InventoryTransactionKey, tx)
+	return context.WithValue(ctx, UserKey, &User{Username: "tech", DisplayName: "Tech Person", Roles: []Role{RoleAdmin}})
+}
+
+// ctxTx returns the inventory transaction in ctx
+func ctxTx(ctx context.Context) *sql.Tx {
+	return ctx.Value(InventoryTransactionKey).(*sql.Tx)
+}
+
+// exec runs query in the inventory transaction in ctx
+func exec(tb testing.TB, ctx context.Context, query string, args ...interface{}) {
+	tb.Helper()
+	if _, err := ctxTx(ctx).Exec(query, args...); err != nil {
+		tb.Fatalf("Could not execute %q: %v", query, err)
+	}
+}
+
+func TestGetChargesNullAmountPaid(t *testing.T) {
+	ctx := testContext(t)
+	exec(t, ctx, "INSERT INTO charges(User, Amount_Paid, Charges) VALUES ('Jane Doe', NULL, 'Screen: 50.00');")
+
+	charges, err := Charges.GetCharges(ctx, "Jane Doe")
+	if err != nil {
+		t.Fatal("GetCharges returned an error:", err)
+	}
+	if len(charges) != 1 || charges[0].AmountPaid != 0 || charges[0].AmountCharged() != 50 {
+		t.Fatalf("GetCharges = %+v, want one charge of 50.00 with nothing paid", charges)
+	}
+}
+
+func TestAddPaymentBalance(t *testing.T) {
+	ctx := testContext(t)
+	exec(t, ctx, "INSERT INTO charges(id, User, Amount_Paid, Charges) VALUES (1, 'Jane Doe', 40, 'Screen: 50.00');")
+
+	//another payment was made after the charge was read with nothing paid
+	ok, err := Charges.AddPayment(ctx, 1, 20, 50, "")
+	if err != nil {
+		t.Fatal("AddPayment returned an error:", err)
+	}
+	if ok {
+		t.Error("AddPayment of more than the balance returned ok")
+	}
+
+	if ok, err = Charges.AddPayment(ctx, 1, 10, 50, ""); err != nil || !ok {
+		t.Fatalf("AddPayment of the balance = %v, %v, want ok", ok, err)
+	}
+
+	c, err := Charges.GetCharge(ctx, 1)
+	if err != nil {
+		t.Fatal("GetCharge returned an error:", err)
+	}
+	if c.AmountPaid != 50 {
+		t.Errorf("AmountPaid = %.2f, want 50.00", c.AmountPaid)
+	}
+}
+
+func TestMatchUserIndex(t *testing.T) {
+	ctx := testContext(t)
+	tx := ctx.Value(InventoryTransactionKey).(*sql.Tx)
+	r := NewSQLiteRepository()
+
+	for table, index := range map[string]string{"devices": "devices_user", "charges": "charges_user"} {
+		rows, err := tx.Query("EXPLAIN QUERY PLAN SELECT id FROM "+table+" WHERE "+r.matchUser+";", "jane doe")
+		if err != nil {
+			t.Fatal("Could not explain query:", err)
+		}
+
+		var plan []string
+		for rows.Next() {
+			var (
+				id, parent, notUsed int
+				detail              string
+			)
+			if err = rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+				t.Fatal("Could not scan query plan:", err)
+			}
+			plan = append(plan, detail)
+		}
+		rows.Close()
+
+		if !strings.Contains(strings.Join(plan, "\n"), "INDEX "+index) {
+			t.Errorf("%s query plan = %q, want %s to be used", table, plan, index)
+		}
+	}
+}
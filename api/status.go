@@ -2,8 +2,7 @@ package api
 
 import (
 	"context"
-	"math"
-	"strconv"
+	"strings"
 )
 
 // ChargeURLBase is the base URL used for charge links
@@ -35,9 +34,10 @@ type StatusType string
 
 // Status types
 const (
-	StatusTypeNone     StatusType = "none"
-	StatusTypeRedBag   StatusType = "red_bag"
-	StatusTypeBlackBag StatusType = "black_bag"
+	StatusTypeNone          StatusType = "none"
+	StatusTypeClassroomOnly StatusType = "classroom_only"
+	StatusTypeRedBag        StatusType = "red_bag"
+	StatusTypeBlackBag      StatusType = "black_bag"
 )
 
 // Status represents the status of a student
@@ -46,97 +46,97 @@ type Status struct {
 	Issues []*Issue   `json:"issues,omitempty"`
 }
 
+// reason returns the first issue that caused the Status
+func (s *Status) reason() string {
+	if len(s.Issues) == 0 {
+		return "No eligible status"
+	}
+	return s.Issues[0].Description
+}
+
 // Status returns the Status of the student
 func (s *Student) Status(ctx context.Context) (*Status, error) {
-	status := &Status{Issues: make([]*Issue, 0)}
+	return s.status(ctx, false)
+}
 
-	if s.T2E2Status == nil {
-		status.Type = StatusTypeNone
-		status.Issues = append(status.Issues, &Issue{Description: "T2E2 Agreement not completed"})
+// Explain returns an Explanation of how the Status of the student is computed
+func (s *Student) Explain(ctx context.Context) (*Explanation, error) {
+	devices, charges, err := s.statusData(ctx, false)
+	if err != nil {
+		return nil, err
 	}
 
-	//check for devices checked out
-	devices, err := getDeviceList(ctx, s.Name())
+	return Rules.Explain(s, devices, charges), nil
+}
+
+// status returns the Status of the student. If loaner is true,
+// devices checked out to the student that aren't loaners are ignored
+func (s *Student) status(ctx context.Context, loaner bool) (*Status, error) {
+	devices, charges, err := s.statusData(ctx, loaner)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(devices) > 0 {
-		status.Type = StatusTypeNone
-		for _, d := range devices {
-			status.Issues = append(status.Issues, &Issue{
-				Description: "Student has device checked out",
-				Link:        DeviceURLBase + strconv.Itoa(d),
-				LinkType:    LinkTypeDevice,
-			})
-		}
-	}
+	return Rules.Evaluate(s, devices, charges), nil
+}
 
-	//check for charges
-	charges, err := getChargeList(ctx, s.Name())
+// statusData returns the devices and charges used to compute the Status of the student. If loaner is true,
+// devices checked out to the student that aren't loaners are ignored
+func (s *Student) statusData(ctx context.Context, loaner bool) ([]int, []*Charge, error) {
+	//check for devices checked out
+	devices, err := Devices.GetDeviceIDs(ctx, s.Name())
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	var noneCharges []*Charge
-	var redCharges []*Charge
-
-	for _, c := range charges {
-		//subtract one to take rounding errors out of the mix
-		if math.Abs(float64(c.AmountCharged()-c.AmountPaid)) < 1 {
-			// charge is paid
-			continue
-		} else if c.AmountPaid >= (c.AmountCharged()/2)-1 {
-			// half or more is paid
-			redCharges = append(redCharges, c)
-		} else if c.AmountCharged()-c.AmountPaid-1 > 0 {
-			// less than half is paid
-			noneCharges = append(noneCharges, c)
+	if loaner {
+		loans, err := Loans.GetLoanDeviceIDs(ctx, s.OtherID)
+		if err != nil {
+			return nil, nil, err
 		}
-	}
 
-	if len(noneCharges) == 0 && len(redCharges) == 0 {
-		if s.T2E2Status != nil && *(s.T2E2Status) == "No" {
-			if status.Type != StatusTypeNone {
-				status.Type = StatusTypeRedBag
-			}
-			status.Issues = append(status.Issues, &Issue{
-				Description: "T2E2 Agreement does not permit student to take home device",
-			})
-		} else {
-			if status.Type != StatusTypeNone {
-				status.Type = StatusTypeBlackBag
+		var loanDevices []int
+		for _, d := range devices {
+			if loans[d] {
+				loanDevices = append(loanDevices, d)
 			}
 		}
-		return status, nil
+		devices = loanDevices
 	}
 
-	if len(noneCharges) == 0 && status.Type != StatusTypeNone {
-		status.Type = StatusTypeRedBag
-	} else {
-		status.Type = StatusTypeNone
+	//check for charges
+	charges, err := Charges.GetCharges(ctx, s.Name())
+	if err != nil {
+		return nil, nil, err
 	}
 
-	for _, c := range noneCharges {
-		status.Issues = append(status.Issues, &Issue{
-			Description:    "Student has charge with less than 50% paid",
-			Link:           ChargeURLBase + strconv.Itoa(c.ID),
-			LinkType:       LinkTypeCharge,
-			LinkValue:      c.AmountCharged() - c.AmountPaid,
-			LinkAdditional: c.Description(),
-		})
+	return devices, charges, nil
+}
 
+// nameKey returns the key used to match a user name to devices and charges.
+// This matches the case and trailing space insensitive comparison done by MySQL
+func nameKey(name string) string {
+	return strings.ToLower(strings.TrimRight(name, " "))
+}
+
+// GetStatuses returns the Status of each of the given students, keyed by OtherID.
+// All devices and charges are loaded at once instead of per student
+func GetStatuses(ctx context.Context, students []*Student) (map[string]*Status, error) {
+	devices, err := Devices.GetDeviceIDMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	charges, err := Charges.GetChargeMap(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	for _, c := range redCharges {
-		status.Issues = append(status.Issues, &Issue{
-			Description:    "Student has unpaid charge",
-			Link:           ChargeURLBase + strconv.Itoa(c.ID),
-			LinkType:       LinkTypeCharge,
-			LinkValue:      c.AmountCharged() - c.AmountPaid,
-			LinkAdditional: c.Description(),
-		})
+	statuses := make(map[string]*Status, len(students))
+	for _, s := range students {
+		key := nameKey(s.Name())
+		statuses[s.OtherID] = Rules.Evaluate(s, devices[key], charges[key])
 	}
 
-	return status, nil
+	return statuses, nil
 }
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestStatusMatchesGetStatuses(t *testing.T) {
+	ctx := testContext(t)
+
+	yes := "Yes"
+	students := []*Student{
+		{FirstName: "Jane", LastName: "Doe", OtherID: "100001", Grade: 9, T2E2Status: &yes},
+		{FirstName: "John", LastName: "Roe", OtherID: "100002", Grade: 10, T2E2Status: &yes},
+		{FirstName: "Ann", LastName: "Poe", OtherID: "100003", Grade: 11, T2E2Status: &yes},
+		{FirstName: "Bob", LastName: "Loe", OtherID: "100004", Grade: 12, T2E2Status: &yes},
+		{FirstName: "Eve", LastName: "Moe", OtherID: "100005", Grade: 8, T2E2Status: &yes},
+	}
+
+	//names differ in case and trailing space from the student's name, and amounts paid may be NULL
+	exec(t, ctx, "INSERT INTO devices(Bag_Tag, Status, User) VALUES ('1001', 'Checked Out', 'JANE DOE ');")
+	exec(t, ctx, "INSERT INTO charges(User, Amount_Paid, Charges) VALUES ('john roe', NULL, 'Screen: 50.00');")
+	exec(t, ctx, "INSERT INTO charges(User, Amount_Paid, Charges) VALUES ('Ann Poe ', 30, 'Screen: 50.00');")
+	exec(t, ctx, "INSERT INTO charges(User, Amount_Paid, Charges) VALUES ('Bob Loe', 50, 'Screen: 50.00');")
+	exec(t, ctx, "INSERT INTO charges(User, Amount_Paid, Charges) VALUES (NULL, NULL, 'Keyboard: 20.00');")
+
+	statuses, err := GetStatuses(ctx, students)
+	if err != nil {
+		t.Fatal("GetStatuses returned an error:", err)
+	}
+
+	for _, s := range students {
+		status, err := s.Status(ctx)
+		if err != nil {
+			t.Fatalf("Status(%s) returned an error: %v", s.OtherID, err)
+		}
+		if !reflect.DeepEqual(status, statuses[s.OtherID]) {
+			t.Errorf("Status(%s) = %+v, GetStatuses = %+v", s.OtherID, status, statuses[s.OtherID])
+		}
+	}
+
+	//check that the devices and charges were matched to the students
+	for otherID, want := range map[string]StatusType{
+		"100001": StatusTypeNone,
+		"100002": StatusTypeNone,
+		"100003": StatusTypeRedBag,
+		"100004": StatusTypeBlackBag,
+		"100005": StatusTypeBlackBag,
+	} {
+		if statuses[otherID].Type != want {
+			t.Errorf("Status(%s) = %s, want %s", otherID, statuses[otherID].Type, want)
+		}
+	}
+}
+
+// benchmarkRoster returns a roster of n students, with a device checked out to every other student
+// and a partially paid charge for every third student
+func benchmarkRoster(b *testing.B, n int) (context.Context, []*Student) {
+	ctx := testContext(b)
+
+	yes := "Yes"
+	students := make([]*Student, 0, n)
+	for i := 0; i < n; i++ {
+		s := &Student{FirstName: "Student", LastName: strconv.Itoa(i), OtherID: fmt.Sprintf("%06d", i), Grade: 9, T2E2Status: &yes}
+		students = append(students, s)
+
+		exec(b, ctx, "INSERT INTO devices(Inventory_Number, Bag_Tag, Status, User) VALUES (?, ?, ?, ?);",
+			fmt.Sprintf("INV%d", i), fmt.Sprintf("%04d", i), "Storage", "")
+		if i%2 == 0 {
+			exec(b, ctx, "UPDATE devices SET Status = 'Checked Out', User = ? WHERE Bag_Tag = ?;", s.Name(), fmt.Sprintf("%04d", i))
+		}
+		if i%3 == 0 {
+			exec(b, ctx, "INSERT INTO charges(User, Amount_Paid, Charges) VALUES (?, 10, 'Screen: 50.00');", s.Name())
+		}
+	}
+
+	return ctx, students
+}
+
+// BenchmarkStudentStatus computes the status of every student in the roster one at a time
+func BenchmarkStudentStatus(b *testing.B) {
+	ctx, students := benchmarkRoster(b, 300)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, s := range students {
+			if _, err := s.Status(ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkGetStatuses computes the status of every student in the roster at once
+func BenchmarkGetStatuses(b *testing.B) {
+	ctx, students := benchmarkRoster(b, 300)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := GetStatuses(ctx, students); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
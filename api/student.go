@@ -2,7 +2,6 @@ package api
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"strings"
 )
@@ -40,6 +39,8 @@ type Student struct {
 	Grade                     int
 	T2E2Status                *string
 	EconomicallyDisadvantaged bool
+	//Campus is the Skyward entity the student is enrolled in
+	Campus string
 }
 
 // Name returns to formalized name of the Student
@@ -47,202 +48,55 @@ func (s Student) Name() string {
 	return fmt.Sprintf("%s %s", s.FirstName, s.LastName)
 }
 
-// GetStudent returns the Student with the given otherID
+// StudentSource is a source of Students, such as a Student Information System
+type StudentSource interface {
+	//GetStudent returns the Student with the given otherID.
+	//If the Student doesn't exist, err will be a request error
+	GetStudent(ctx context.Context, otherID string) (*Student, error)
+
+	//GetStudentList returns a list of all active Students
+	GetStudentList(ctx context.Context) ([]*Student, error)
+}
+
+// Students is the StudentSource used by GetStudent and GetStudentList
+var Students StudentSource = &SkywardSource{}
+
+// GetStudent returns the Student with the given otherID.
+// If Roster is set, the Student is returned from it, falling back to Students if the Student isn't cached
 func GetStudent(ctx context.Context, otherID string) (*Student, error) {
-	tx := ctx.Value(SkywardTransactionKey).(*sql.Tx)
-
-	s := &Student{}
-
-	var (
-		firstName *string
-		lastName  *string
-	)
-
-	err := tx.QueryRow(`
-	SELECT
-		name."FIRST-NAME" AS First_Name,
-		name."LAST-NAME" AS Last_Name,
-		student."OTHER-ID" AS Other_I_D,
-		(12 - (student."GRAD-YR" - entity."SCHOOL-YEAR")) AS Grade,
-		data."STATUS" AS Status,
-        CASE
-            WHEN eco."ECO-DIS-CODE" = '00' THEN CAST(0 AS BIT)
-            WHEN eco."ECO-DIS-CODE" IS NULL THEN CAST(0 AS BIT)
-            ELSE CAST(1 AS BIT)
-        END AS Economically_Disadvantaged
-	FROM PUB.NAME AS name
-	INNER JOIN PUB."STUDENT" AS student ON
-		name."NAME-ID" = student."NAME-ID"
-
-	INNER JOIN PUB."STUDENT-ENTITY" as sentity ON
-		sentity."STUDENT-ID" = student."STUDENT-ID"
-
-	INNER JOIN PUB."ENTITY" as entity ON
-		entity."ENTITY-ID" = sentity."ENTITY-ID"
-
-    LEFT JOIN (
-		SELECT
-			data."QUDDAT-SRC-ID" AS "STUDENT-ID",
-			data."QUDDAT-CHAR" AS "STATUS"
-
-		FROM PUB."QUDDAT-DATA" AS data
-
-		INNER JOIN PUB."QUDTBL-TABLES" AS tables ON
-			data."QUDDAT-STORAGE-TYPE" = 'Custom Student' AND
-			data."QUDTBL-TABLE-ID" = tables."QUDTBL-TABLE-ID" AND
-			tables."QUDTBL-DESC" = 'T2E2'
-
-		INNER JOIN PUB."QUDFLD-FIELDS" AS fields ON
-			data."QUDFLD-FIELD-ID" = fields."QUDFLD-FIELD-ID" AND
-			fields."QUDFLD-FIELD-LABEL" = 'Can_Take_Chromebook_Home'
-    ) AS data ON
-		student."STUDENT-ID" = data."STUDENT-ID"
-
-    LEFT JOIN (
-        SELECT
-            tran."NAME-ID",
-            code."FS-LUN-CODE-STATE" AS "ECO-DIS-CODE"
-
-        FROM PUB."FS-TRANSACTION" AS tran
-
-        INNER JOIN (
-            SELECT "NAME-ID", MAX("FS-TRAN-EFFECTIVE-DATE") AS "FS-TRAN-EFFECTIVE-DATE"
-            FROM PUB."FS-TRANSACTION"
-            GROUP BY "NAME-ID"
-        ) AS filter ON
-                filter."NAME-ID" = tran."NAME-ID" AND
-                filter."FS-TRAN-EFFECTIVE-DATE" = tran."FS-TRAN-EFFECTIVE-DATE"
-
-        INNER JOIN PUB."FS-LUN-CODE" AS code ON
-            code."FS-LUN-CODE-ID" = tran."FS-LUN-CODE-ID"
-    ) AS eco ON
-        name."NAME-ID" = eco."NAME-ID"
-
-	WHERE sentity."STUDENT-STATUS" = 'A' AND
-	student."GRAD-YR" >= entity."SCHOOL-YEAR" AND
-	(student."GRAD-YR" - entity."SCHOOL-YEAR") < 6 AND
-	student."OTHER-ID" = ?
-
-	WITH (NOLOCK)
-	`, otherID).Scan(
-		&(firstName),
-		&(lastName),
-		&(s.OtherID),
-		&(s.Grade),
-		&(s.T2E2Status),
-		&(s.EconomicallyDisadvantaged),
-	)
-
-	switch {
-	case err == sql.ErrNoRows:
-		return nil, &Error{Description: fmt.Sprintf("Student could not be found with OtherID: %s", otherID), Err: err, RequestError: true}
-	case err != nil:
-		return nil, &Error{Description: fmt.Sprintf("Could not query Student(%s)", otherID), Err: err}
+	if Roster != nil {
+		if s := Roster.get(otherID); s != nil {
+			return s, nil
+		}
 	}
 
-	s.FirstName = formalizeName(*firstName)
-	s.LastName = formalizeName(*lastName)
+	s, err := Students.GetStudent(ctx, otherID)
+	if err != nil {
+		return nil, err
+	}
+
+	if Roster != nil {
+		Roster.add(s)
+	}
 
 	return s, nil
 }
 
-// GetStudentList returns a list of all Students
+// GetStudentList returns a list of all Students. If Roster is set, the list is returned from it
 func GetStudentList(ctx context.Context) ([]*Student, error) {
-	tx := ctx.Value(SkywardTransactionKey).(*sql.Tx)
-
-	rows, err := tx.Query(`
-	SELECT
-		name."FIRST-NAME" AS First_Name,
-		name."LAST-NAME" AS Last_Name,
-		student."OTHER-ID" AS Other_I_D,
-		(12 - (student."GRAD-YR" - entity."SCHOOL-YEAR")) AS Grade,
-		data."STATUS" AS Status,
-        CASE
-            WHEN eco."ECO-DIS-CODE" = '00' THEN CAST(0 AS BIT)
-            WHEN eco."ECO-DIS-CODE" IS NULL THEN CAST(0 AS BIT)
-            ELSE CAST(1 AS BIT)
-        END AS Economically_Disadvantaged
-	FROM PUB.NAME AS name
-	INNER JOIN PUB."STUDENT" AS student ON
-		name."NAME-ID" = student."NAME-ID"
-
-	INNER JOIN PUB."STUDENT-ENTITY" as sentity ON
-		sentity."STUDENT-ID" = student."STUDENT-ID"
-
-	INNER JOIN PUB."ENTITY" as entity ON
-		entity."ENTITY-ID" = sentity."ENTITY-ID"
-
-    LEFT JOIN (
-		SELECT
-			data."QUDDAT-SRC-ID" AS "STUDENT-ID",
-			data."QUDDAT-CHAR" AS "STATUS"
-
-		FROM PUB."QUDDAT-DATA" AS data
-
-		INNER JOIN PUB."QUDTBL-TABLES" AS tables ON
-			data."QUDDAT-STORAGE-TYPE" = 'Custom Student' AND
-			data."QUDTBL-TABLE-ID" = tables."QUDTBL-TABLE-ID" AND
-			tables."QUDTBL-DESC" = 'T2E2'
-
-		INNER JOIN PUB."QUDFLD-FIELDS" AS fields ON
-			data."QUDFLD-FIELD-ID" = fields."QUDFLD-FIELD-ID" AND
-			fields."QUDFLD-FIELD-LABEL" = 'Can_Take_Chromebook_Home'
-    ) AS data ON
-		student."STUDENT-ID" = data."STUDENT-ID"
-
-    LEFT JOIN (
-        SELECT
-            tran."NAME-ID",
-            code."FS-LUN-CODE-STATE" AS "ECO-DIS-CODE"
-
-        FROM PUB."FS-TRANSACTION" AS tran
-
-        INNER JOIN (
-            SELECT "NAME-ID", MAX("FS-TRAN-EFFECTIVE-DATE") AS "FS-TRAN-EFFECTIVE-DATE"
-            FROM PUB."FS-TRANSACTION"
-            GROUP BY "NAME-ID"
-        ) AS filter ON
-                filter."NAME-ID" = tran."NAME-ID" AND
-                filter."FS-TRAN-EFFECTIVE-DATE" = tran."FS-TRAN-EFFECTIVE-DATE"
-
-        INNER JOIN PUB."FS-LUN-CODE" AS code ON
-            code."FS-LUN-CODE-ID" = tran."FS-LUN-CODE-ID"
-    ) AS eco ON
-        name."NAME-ID" = eco."NAME-ID"
-
-	WHERE sentity."STUDENT-STATUS" = 'A' AND
-	student."GRAD-YR" >= entity."SCHOOL-YEAR" AND
-	(student."GRAD-YR" - entity."SCHOOL-YEAR") < 6
-
-	WITH (NOLOCK)
-	`)
-	if err != nil {
-		return nil, &Error{Description: "Could not query Student list", Err: err}
+	if Roster != nil {
+		return Roster.list(), nil
 	}
-	defer rows.Close()
 
-	var students []*Student
-
-	var (
-		firstName *string
-		lastName  *string
-	)
-
-	for rows.Next() {
-		s := new(Student)
-		if err := rows.Scan(&(firstName), &(lastName), &(s.OtherID), &(s.Grade), &(s.T2E2Status), &(s.EconomicallyDisadvantaged)); err != nil {
-			return nil, &Error{Description: "Could not scan Student row", Err: err}
-		}
-
-		s.FirstName = formalizeName(*firstName)
-		s.LastName = formalizeName(*lastName)
-
-		students = append(students, s)
-	}
+	return Students.GetStudentList(ctx)
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, &Error{Description: "Could not scan Student rows", Err: err}
-	}
+// GetDevices returns the devices checked out to the Student
+func (s *Student) GetDevices(ctx context.Context) ([]*Device, error) {
+	return Devices.GetDevices(ctx, s.Name())
+}
 
-	return students, nil
+// GetCharges returns the Student's charges
+func (s *Student) GetCharges(ctx context.Context) ([]*Charge, error) {
+	return Charges.GetCharges(ctx, s.Name())
 }
@@ -0,0 +1,42 @@
+package api
+
+import "testing"
+
+func TestStudentDevicesCharges(t *testing.T) {
+	ctx := oneRosterContext(t)
+	exec(t, ctx, "INSERT INTO devices(Inventory_Number, Serial_Number, Bag_Tag, Status, User, Model) VALUES ('INV1', 'SER1', '1001', 'Checked Out', 'Jane Doe', 'Chromebook');")
+	exec(t, ctx, "INSERT INTO devices(Inventory_Number, Serial_Number, Bag_Tag, Status, User, Model) VALUES ('INV2', 'SER2', '1002', 'Checked Out', 'jane doe ', 'Chromebook');")
+	exec(t, ctx, "INSERT INTO devices(Inventory_Number, Serial_Number, Bag_Tag, Status, User, Model) VALUES ('INV3', 'SER3', '1003', 'Checked Out', 'John Roe', 'Chromebook');")
+	exec(t, ctx, "INSERT INTO charges(User, Amount_Paid, Charges) VALUES ('Jane Doe', 20, 'Screen: 45.00|Keyboard: 30.00');")
+	exec(t, ctx, "INSERT INTO charges(User, Amount_Paid, Charges) VALUES ('John Roe', 0, 'Screen: 45.00');")
+
+	s, err := GetStudent(ctx, "100001")
+	if err != nil {
+		t.Fatal("GetStudent returned an error:", err)
+	}
+
+	devices, err := s.GetDevices(ctx)
+	if err != nil {
+		t.Fatal("GetDevices returned an error:", err)
+	}
+	if len(devices) != 2 || devices[0].BagTag != "1001" || devices[1].BagTag != "1002" || devices[0].SerialNumber != "SER1" || devices[0].Model != "Chromebook" {
+		t.Errorf("GetDevices = %+v, want 1001 and 1002", devices)
+	}
+
+	charges, err := s.GetCharges(ctx)
+	if err != nil {
+		t.Fatal("GetCharges returned an error:", err)
+	}
+	if len(charges) != 1 || charges[0].AmountCharged() != 75 || charges[0].AmountPaid != 20 || len(charges[0].Items()) != 2 {
+		t.Errorf("GetCharges = %+v, want one charge of 75.00 with 20.00 paid and two items", charges)
+	}
+
+	s, err = GetStudent(ctx, "100002")
+	if err != nil {
+		t.Fatal("GetStudent returned an error:", err)
+	}
+	exec(t, ctx, "UPDATE devices SET User = '', Status = 'Storage' WHERE Bag_Tag = '1003';")
+	if devices, err = s.GetDevices(ctx); err != nil || len(devices) != 0 {
+		t.Errorf("GetDevices = %+v, %v, want no devices", devices, err)
+	}
+}
@@ -6,22 +6,75 @@ import (
 	auth "github.com/korylprince/go-ad-auth/v3"
 )
 
+// Role is a permission granted to a User by their group membership
+type Role string
+
+// Roles
+const (
+	//RoleViewer can read students, statuses, devices, and loans
+	RoleViewer Role = "viewer"
+	//RoleCheckout can check out, check in, swap, and loan devices
+	RoleCheckout Role = "checkout"
+	//RoleCashier can record payments
+	RoleCashier Role = "cashier"
+	//RoleAdmin has every role, and can read the audit log and revoke sessions
+	RoleAdmin Role = "admin"
+)
+
 // AuthConfig holds configuration for connecting to an authentication source
 type AuthConfig struct {
 	ADConfig *auth.Config
-	Group    string
+	//Group is an optional group whose members have the checkout and cashier roles
+	Group string
+	//AdminGroup is an optional group whose members have the admin role
+	AdminGroup string
+	//RoleGroups maps group names to the role their members have
+	RoleGroups map[string]Role
+}
+
+// roles returns the roles granted by each group
+func (c *AuthConfig) roles() map[string][]Role {
+	roles := make(map[string][]Role)
+	if c.Group != "" {
+		roles[c.Group] = append(roles[c.Group], RoleCheckout, RoleCashier)
+	}
+	if c.AdminGroup != "" {
+		roles[c.AdminGroup] = append(roles[c.AdminGroup], RoleAdmin)
+	}
+	for g, r := range c.RoleGroups {
+		roles[g] = append(roles[g], r)
+	}
+	return roles
 }
 
 // User represents an Active Directory User
 type User struct {
 	Username    string `json:"username"`
 	DisplayName string `json:"display_name"`
+	Roles       []Role `json:"roles"`
+}
+
+// HasRole returns whether or not the user has the given role.
+// Admins have every role, and every role can do what RoleViewer can
+func (u *User) HasRole(role Role) bool {
+	for _, r := range u.Roles {
+		if r == role || r == RoleAdmin || role == RoleViewer {
+			return true
+		}
+	}
+	return false
 }
 
 // Authenticate authenticates the given username and password against the given config,
 // returning user information if successful, nil if unsuccessful, or an error if one occurred.
 func Authenticate(config *AuthConfig, username, password string) (*User, error) {
-	status, entry, groups, err := auth.AuthenticateExtended(config.ADConfig, username, password, []string{"displayName"}, []string{config.Group})
+	groupRoles := config.roles()
+	var checkGroups []string
+	for g := range groupRoles {
+		checkGroups = append(checkGroups, g)
+	}
+
+	status, entry, groups, err := auth.AuthenticateExtended(config.ADConfig, username, password, []string{"displayName"}, checkGroups)
 	if err != nil {
 		return nil, fmt.Errorf("Error attempting to authenticate as %s: %v", username, err)
 	}
@@ -38,5 +91,16 @@ func Authenticate(config *AuthConfig, username, password string) (*User, error)
 		return nil, fmt.Errorf("displayName doesn't exist for username: %s", username)
 	}
 
-	return &User{Username: username, DisplayName: entry.GetAttributeValue("displayName")}, nil
+	user := &User{Username: username, DisplayName: entry.GetAttributeValue("displayName")}
+	has := make(map[Role]bool)
+	for _, g := range groups {
+		for _, r := range groupRoles[g] {
+			if !has[r] {
+				has[r] = true
+				user.Roles = append(user.Roles, r)
+			}
+		}
+	}
+
+	return user, nil
 }
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestHasRole(t *testing.T) {
+	for _, test := range []struct {
+		roles []Role
+		want  map[Role]bool
+	}{
+		{nil, map[Role]bool{RoleViewer: false, RoleCheckout: false, RoleCashier: false, RoleAdmin: false}},
+		{[]Role{RoleViewer}, map[Role]bool{RoleViewer: true, RoleCheckout: false, RoleCashier: false, RoleAdmin: false}},
+		{[]Role{RoleCheckout}, map[Role]bool{RoleViewer: true, RoleCheckout: true, RoleCashier: false, RoleAdmin: false}},
+		{[]Role{RoleCashier}, map[Role]bool{RoleViewer: true, RoleCheckout: false, RoleCashier: true, RoleAdmin: false}},
+		{[]Role{RoleAdmin}, map[Role]bool{RoleViewer: true, RoleCheckout: true, RoleCashier: true, RoleAdmin: true}},
+	} {
+		u := &User{Username: "tech", Roles: test.roles}
+		for role, want := range test.want {
+			if u.HasRole(role) != want {
+				t.Errorf("%v: HasRole(%s) = %v, want %v", test.roles, role, !want, want)
+			}
+		}
+	}
+}
+
+func TestAuthConfigRoles(t *testing.T) {
+	c := &AuthConfig{
+		Group:      "Admin Group",
+		AdminGroup: "Tech Admins",
+		RoleGroups: map[string]Role{"Front Desk": RoleViewer, "Admin Group": RoleViewer},
+	}
+
+	roles := c.roles()
+	for _, r := range roles {
+		sort.Slice(r, func(i, j int) bool { return r[i] < r[j] })
+	}
+
+	want := map[string][]Role{
+		"Admin Group": {RoleCashier, RoleCheckout, RoleViewer},
+		"Tech Admins": {RoleAdmin},
+		"Front Desk":  {RoleViewer},
+	}
+	if !reflect.DeepEqual(roles, want) {
+		t.Errorf("roles = %v, want %v", roles, want)
+	}
+
+	if roles = (&AuthConfig{}).roles(); len(roles) != 0 {
+		t.Errorf("roles without groups = %v, want none", roles)
+	}
+}
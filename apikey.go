@@ -0,0 +1,86 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/korylprince/bisd-device-checkout-server/httpapi"
+)
+
+const apiKeyUsage = `Usage: %s apikey <command>
+
+Commands:
+  create <name> <scopes> [expires]  create a key with comma separated scopes (%s),
+                                    optionally expiring on expires (YYYY-MM-DD). The key is only shown once
+  list                              show keys
+  revoke <name>                     remove a key
+`
+
+// runAPIKey runs the apikey subcommand with the given args
+func runAPIKey(db *sql.DB, args []string) {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, apiKeyUsage, os.Args[0], strings.Join(httpapi.Scopes, ", "))
+		os.Exit(2)
+	}
+
+	if len(args) == 0 {
+		usage()
+	}
+
+	switch args[0] {
+	case "create":
+		if len(args) < 3 || len(args) > 4 {
+			usage()
+		}
+		var expires *time.Time
+		if len(args) == 4 {
+			t, err := time.ParseInLocation("2006-01-02", args[3], time.Local)
+			if err != nil {
+				log.Fatalln("Invalid expiration date:", args[3])
+			}
+			expires = &t
+		}
+		key, err := httpapi.CreateAPIKey(db, args[1], strings.Split(args[2], ","), expires)
+		if err != nil {
+			log.Fatalln("Could not create API key:", err)
+		}
+		fmt.Println(key)
+	case "list":
+		if len(args) != 1 {
+			usage()
+		}
+		keys, err := httpapi.ListAPIKeys(db)
+		if err != nil {
+			log.Fatalln("Could not list API keys:", err)
+		}
+		format := func(t *time.Time, none string) string {
+			if t == nil {
+				return none
+			}
+			return t.Local().Format("2006-01-02 15:04:05")
+		}
+		for _, k := range keys {
+			fmt.Printf("%-24s %-24s created: %s, expires: %s, last used: %s\n",
+				k.Name, strings.Join(k.Scopes, ","), format(&(k.Created), ""), format(k.Expires, "never"), format(k.LastUsed, "never"),
+			)
+		}
+	case "revoke":
+		if len(args) != 2 {
+			usage()
+		}
+		ok, err := httpapi.DeleteAPIKey(db, args[1])
+		if err != nil {
+			log.Fatalln("Could not revoke API key:", err)
+		}
+		if !ok {
+			log.Fatalln("API key doesn't exist:", args[1])
+		}
+		log.Println("Revoked", args[1])
+	default:
+		usage()
+	}
+}
@@ -2,30 +2,46 @@ package main
 
 import (
 	"log"
+	"net"
 	"strings"
 
 	"github.com/kelseyhightower/envconfig"
+	"github.com/korylprince/bisd-device-checkout-server/api"
+	"github.com/korylprince/bisd-device-checkout-server/httpapi"
 	auth "github.com/korylprince/go-ad-auth/v3"
 )
 
 // Config represents options given in the environment
 type Config struct {
-	SessionExpiration int //in minutes; default: 60
-
-	LDAPServer   string //required
-	LDAPPort     int    //default: 389
-	LDAPBaseDN   string //required
-	LDAPGroup    string //optional
-	LDAPSecurity string //default: none
-	ldapSecurity auth.SecurityType
-
-	SQLDriver    string //required
-	InventoryDSN string //required
-	SkywardDSN   string //required
-
-	APIKey     string
-	ListenAddr string //addr format used for net.Dial; required
-	Prefix     string //url prefix to mount api to without trailing slash
+	SessionExpiration int    //in minutes; default: 60
+	SessionStore      string //memory or sql; default: memory
+	UndoWindow        int    //in minutes; default: 15
+
+	LDAPServer     string            //required
+	LDAPPort       int               //default: 389
+	LDAPBaseDN     string            //required
+	LDAPGroup      string            //optional; members have the checkout and cashier roles
+	LDAPAdminGroup string            //optional; members have the admin role
+	LDAPRoleGroups map[string]string //group:role pairs, where role is viewer, checkout, cashier, or admin; optional
+	ldapRoleGroups map[string]api.Role
+	LDAPSecurity   string //default: none
+	ldapSecurity   auth.SecurityType
+
+	SQLDriver     string //mysql or sqlite3; required
+	InventoryDSN  string //required
+	StudentSource string //skyward or oneroster; default: skyward
+	SkywardDSN    string //required if StudentSource is skyward
+	OneRosterDir  string //directory containing users.csv and demographics.csv; required if StudentSource is oneroster
+
+	RosterRefresh int //in minutes; 0 disables the roster cache; default: 0
+
+	RulesFile string //path to JSON eligibility rules; optional
+
+	APIKey         string
+	ListenAddr     string   //addr format used for net.Dial; required
+	TrustedProxies []string //IP addresses or CIDR ranges of reverse proxies whose X-Forwarded-For header is trusted; optional
+	trustedProxies []*net.IPNet
+	Prefix         string //url prefix to mount api to without trailing slash
 }
 
 var config = &Config{}
@@ -45,6 +61,34 @@ func init() {
 	if config.SessionExpiration == 0 {
 		config.SessionExpiration = 60
 	}
+
+	if config.UndoWindow == 0 {
+		config.UndoWindow = 15
+	}
+
+	switch strings.ToLower(config.SessionStore) {
+	case "", "memory":
+		config.SessionStore = "memory"
+	case "sql":
+		config.SessionStore = "sql"
+	default:
+		log.Fatalln("Invalid INVENTORY_SESSIONSTORE:", config.SessionStore)
+	}
+
+	checkEmpty(config.SQLDriver, "SQLDRIVER")
+	if config.SQLDriver != "mysql" && config.SQLDriver != "sqlite3" {
+		log.Fatalln("Invalid INVENTORY_SQLDRIVER (must be mysql or sqlite3):", config.SQLDriver)
+	}
+	checkEmpty(config.InventoryDSN, "INVENTORYDSN")
+
+	if config.SQLDriver == "mysql" && !strings.Contains(config.InventoryDSN, "?parseTime=true") {
+		log.Fatalln("mysql DSN must contain \"?parseTime=true\"")
+	}
+}
+
+// checkServerConfig checks the options only needed to run the server,
+// so subcommands can run with only the database configured
+func checkServerConfig() {
 	checkEmpty(config.LDAPServer, "LDAPSERVER")
 
 	if config.LDAPPort == 0 {
@@ -64,13 +108,32 @@ func init() {
 		log.Fatalln("Invalid INVENTORY_LDAPSECURITY:", config.LDAPSecurity)
 	}
 
-	checkEmpty(config.SQLDriver, "SQLDRIVER")
-	checkEmpty(config.InventoryDSN, "INVENTORYDSN")
-	checkEmpty(config.SkywardDSN, "SKYWARDDSN")
+	config.ldapRoleGroups = make(map[string]api.Role)
+	for group, role := range config.LDAPRoleGroups {
+		switch r := api.Role(strings.ToLower(role)); r {
+		case api.RoleViewer, api.RoleCheckout, api.RoleCashier, api.RoleAdmin:
+			config.ldapRoleGroups[group] = r
+		default:
+			log.Fatalf("Invalid role for group %s in INVENTORY_LDAPROLEGROUPS: %s\n", group, role)
+		}
+	}
 
-	if config.SQLDriver == "mysql" && !strings.Contains(config.InventoryDSN, "?parseTime=true") {
-		log.Fatalln("mysql DSN must contain \"?parseTime=true\"")
+	switch strings.ToLower(config.StudentSource) {
+	case "", "skyward":
+		config.StudentSource = "skyward"
+		checkEmpty(config.SkywardDSN, "SKYWARDDSN")
+	case "oneroster":
+		config.StudentSource = "oneroster"
+		checkEmpty(config.OneRosterDir, "ONEROSTERDIR")
+	default:
+		log.Fatalln("Invalid INVENTORY_STUDENTSOURCE:", config.StudentSource)
 	}
 
 	checkEmpty(config.ListenAddr, "LISTENADDR")
+
+	proxies, err := httpapi.ParseTrustedProxies(config.TrustedProxies)
+	if err != nil {
+		log.Fatalln("Invalid INVENTORY_TRUSTEDPROXIES:", err)
+	}
+	config.trustedProxies = proxies
 }
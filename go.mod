@@ -9,6 +9,7 @@ require (
 	github.com/gorilla/mux v1.8.0
 	github.com/kelseyhightower/envconfig v1.4.0
 	github.com/korylprince/go-ad-auth/v3 v3.3.0
+	github.com/mattn/go-sqlite3 v1.14.16
 )
 
 require (
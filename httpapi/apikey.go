@@ -0,0 +1,216 @@
+package httpapi
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// API key scopes. Each scope allows access to one /nosession route
+const (
+	//ScopeStudentList allows GET /nosession/students
+	ScopeStudentList = "students"
+	//ScopeStudentStatuses allows GET /nosession/students?status=true
+	ScopeStudentStatuses = "statuses"
+	//ScopeStudentStatus allows GET /nosession/students/:otherID/status
+	ScopeStudentStatus = "status"
+	//ScopeAll allows access to every /nosession route
+	ScopeAll = "*"
+)
+
+// Scopes are the valid API key scopes
+var Scopes = []string{ScopeStudentList, ScopeStudentStatuses, ScopeStudentStatus, ScopeAll}
+
+// LegacyAPIKeyName is the name of the API key given in the server configuration
+const LegacyAPIKeyName = "default"
+
+// apiKeyLastUsedInterval is how often an API key's last use is recorded
+const apiKeyLastUsedInterval = time.Minute
+
+// APIKey represents a named API key. Only a hash of the key is stored
+type APIKey struct {
+	Name     string
+	Scopes   []string
+	Created  time.Time
+	Expires  *time.Time
+	LastUsed *time.Time
+
+	id   int
+	hash string
+}
+
+// HasScope returns whether or not the key allows access to scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAll {
+			return true
+		}
+	}
+	return false
+}
+
+// hashAPIKey returns the hash of key stored in the database
+func hashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// ValidScope returns whether or not scope is a valid API key scope
+func ValidScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAPIKey creates a new API key with the given name, scopes, and optional expiration, and returns the key.
+// The key can't be retrieved later
+func CreateAPIKey(db *sql.DB, name string, scopes []string, expires *time.Time) (string, error) {
+	if name == "" || strings.EqualFold(name, LegacyAPIKeyName) {
+		return "", fmt.Errorf(`Invalid API key name "%s"`, name)
+	}
+
+	if len(scopes) == 0 {
+		return "", fmt.Errorf("API key must have at least one scope")
+	}
+
+	for _, s := range scopes {
+		if !ValidScope(s) {
+			return "", fmt.Errorf(`Invalid API key scope "%s"`, s)
+		}
+	}
+
+	key := randString(64)
+
+	_, err := db.Exec("INSERT INTO api_keys(name, hash, scopes, created, expires) VALUES (?, ?, ?, ?, ?);",
+		name, hashAPIKey(key), strings.Join(scopes, ","), time.Now(), expires,
+	)
+	if err != nil {
+		return "", fmt.Errorf("Could not create API key: %v", err)
+	}
+
+	return key, nil
+}
+
+// queryAPIKeys returns the API keys matching the given SQL condition and args
+func queryAPIKeys(db *sql.DB, condition string, args ...interface{}) ([]*APIKey, error) {
+	rows, err := db.Query("SELECT id, name, hash, scopes, created, expires, last_used FROM api_keys WHERE "+condition+";", args...)
+	if err != nil {
+		return nil, fmt.Errorf("Could not query API keys: %v", err)
+	}
+	defer rows.Close()
+
+	var keys []*APIKey
+
+	for rows.Next() {
+		var (
+			k      = new(APIKey)
+			scopes string
+		)
+		if err = rows.Scan(&(k.id), &(k.Name), &(k.hash), &scopes, &(k.Created), &(k.Expires), &(k.LastUsed)); err != nil {
+			return nil, fmt.Errorf("Could not scan API key: %v", err)
+		}
+		k.Scopes = strings.Split(scopes, ",")
+		keys = append(keys, k)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("Could not scan API keys: %v", err)
+	}
+
+	return keys, nil
+}
+
+// ListAPIKeys returns all API keys in the database
+func ListAPIKeys(db *sql.DB) ([]*APIKey, error) {
+	return queryAPIKeys(db, "1=1 ORDER BY name")
+}
+
+// DeleteAPIKey removes the API key with the given name, returning false if it doesn't exist
+func DeleteAPIKey(db *sql.DB, name string) (bool, error) {
+	res, err := db.Exec("DELETE FROM api_keys WHERE name = ?;", name)
+	if err != nil {
+		return false, fmt.Errorf("Could not remove API key %s: %v", name, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("Could not remove API key %s: %v", name, err)
+	}
+
+	return n > 0, nil
+}
+
+// APIKeyStore checks API keys against the api_keys table and the legacy key given in the server configuration
+type APIKeyStore struct {
+	db     *sql.DB
+	legacy string
+}
+
+// NewAPIKeyStore returns a new APIKeyStore using db. If legacy is not empty, it is allowed access to every scope
+func NewAPIKeyStore(db *sql.DB, legacy string) *APIKeyStore {
+	s := &APIKeyStore{db: db}
+	if legacy != "" {
+		s.legacy = hashAPIKey(legacy)
+	}
+	return s
+}
+
+// Configured returns whether or not any API keys are configured
+func (s *APIKeyStore) Configured() (bool, error) {
+	if s.legacy != "" {
+		return true, nil
+	}
+
+	var id int
+	err := s.db.QueryRow("SELECT id FROM api_keys LIMIT 1;").Scan(&id)
+	switch {
+	case err == sql.ErrNoRows:
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("Could not query API keys: %v", err)
+	}
+
+	return true, nil
+}
+
+// Check returns the APIKey matching key, or nil if no unexpired key matches.
+// Keys are looked up by their hash, so the lookup doesn't reveal anything about the key.
+// The key's last use is only recorded once every apiKeyLastUsedInterval
+func (s *APIKeyStore) Check(key string) (*APIKey, error) {
+	hash := hashAPIKey(key)
+
+	if s.legacy != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(s.legacy)) == 1 {
+		return &APIKey{Name: LegacyAPIKeyName, Scopes: []string{ScopeAll}, hash: s.legacy}, nil
+	}
+
+	keys, err := queryAPIKeys(s.db, "hash = ?", hash)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	if len(keys) == 0 || (keys[0].Expires != nil && !keys[0].Expires.After(now)) {
+		return nil, nil
+	}
+
+	k := keys[0]
+
+	if k.LastUsed != nil && now.Sub(*(k.LastUsed)) < apiKeyLastUsedInterval {
+		return k, nil
+	}
+
+	if _, err = s.db.Exec("UPDATE api_keys SET last_used = ? WHERE id = ?;", now, k.id); err != nil {
+		return nil, fmt.Errorf("Could not update API key %s: %v", k.Name, err)
+	}
+	k.LastUsed = &now
+
+	return k, nil
+}
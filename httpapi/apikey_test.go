@@ -0,0 +1,131 @@
+package httpapi
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/korylprince/bisd-device-checkout-server/migrations"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// testDB returns a new, migrated, in-memory SQLite database
+func testDB(tb testing.TB) *sql.DB {
+	tb.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		tb.Fatal("Could not open database:", err)
+	}
+	//each connection to :memory: is a separate database
+	db.SetMaxOpenConns(1)
+	tb.Cleanup(func() { db.Close() })
+
+	if _, err = migrations.Up(db, "sqlite3", 0); err != nil {
+		tb.Fatal("Could not migrate database:", err)
+	}
+
+	return db
+}
+
+func TestAPIKeyStoreCheck(t *testing.T) {
+	db := testDB(t)
+	s := NewAPIKeyStore(db, "")
+
+	key, err := CreateAPIKey(db, "grades", []string{ScopeStudentList}, nil)
+	if err != nil {
+		t.Fatal("CreateAPIKey returned an error:", err)
+	}
+
+	expires := time.Now().Add(-time.Minute)
+	expired, err := CreateAPIKey(db, "old", []string{ScopeAll}, &expires)
+	if err != nil {
+		t.Fatal("CreateAPIKey returned an error:", err)
+	}
+
+	for _, k := range []string{"bogus", expired} {
+		if match, err := s.Check(k); err != nil || match != nil {
+			t.Errorf("Check(%q) = %v, %v, want nil", k, match, err)
+		}
+	}
+
+	match, err := s.Check(key)
+	if err != nil || match == nil || match.Name != "grades" || !match.HasScope(ScopeStudentList) || match.HasScope(ScopeStudentStatus) {
+		t.Fatalf("Check = %+v, %v, want the grades key", match, err)
+	}
+	if match.LastUsed == nil {
+		t.Fatal("Check didn't record the key's last use")
+	}
+
+	//the last use isn't written again within apiKeyLastUsedInterval
+	lastUsed := time.Now().Add(-apiKeyLastUsedInterval / 2)
+	if _, err = db.Exec("UPDATE api_keys SET last_used = ? WHERE name = 'grades';", lastUsed); err != nil {
+		t.Fatal("Could not update key:", err)
+	}
+	if match, err = s.Check(key); err != nil || match == nil || !match.LastUsed.Equal(lastUsed) {
+		t.Fatalf("Check = %+v, %v, want last use unchanged", match, err)
+	}
+}
+
+func TestAPIKeyStoreLegacy(t *testing.T) {
+	db := testDB(t)
+
+	if configured, err := NewAPIKeyStore(db, "").Configured(); err != nil || configured {
+		t.Errorf("Configured with no keys = %v, %v, want false", configured, err)
+	}
+
+	s := NewAPIKeyStore(db, "legacykey")
+	if configured, err := s.Configured(); err != nil || !configured {
+		t.Errorf("Configured with a legacy key = %v, %v, want true", configured, err)
+	}
+
+	match, err := s.Check("legacykey")
+	if err != nil || match == nil || match.Name != LegacyAPIKeyName || !match.HasScope(ScopeStudentStatus) {
+		t.Errorf("Check of legacy key = %+v, %v, want the legacy key", match, err)
+	}
+}
+
+func TestAuthKeyMiddleware(t *testing.T) {
+	db := testDB(t)
+	s := NewAPIKeyStore(db, "")
+
+	next := func(w http.ResponseWriter, r *http.Request) *handlerResponse {
+		return &handlerResponse{Code: http.StatusOK}
+	}
+	h := authKeyMiddleware(next, s, ScopeStudentList)
+
+	do := func(key string) int {
+		r := httptest.NewRequest("GET", "/api/1.4/nosession/students", nil)
+		if key != "" {
+			r.Header.Set("Authorization", "Bearer "+key)
+		}
+		return h(httptest.NewRecorder(), r).Code
+	}
+
+	//routes aren't found when no keys are configured, as before API keys were added
+	if code := do("bogus"); code != http.StatusNotFound {
+		t.Errorf("request with no keys configured = %d, want %d", code, http.StatusNotFound)
+	}
+
+	key, err := CreateAPIKey(db, "grades", []string{ScopeStudentList}, nil)
+	if err != nil {
+		t.Fatal("CreateAPIKey returned an error:", err)
+	}
+	other, err := CreateAPIKey(db, "status", []string{ScopeStudentStatus}, nil)
+	if err != nil {
+		t.Fatal("CreateAPIKey returned an error:", err)
+	}
+
+	for k, want := range map[string]int{
+		"":      http.StatusUnauthorized,
+		"bogus": http.StatusUnauthorized,
+		other:   http.StatusForbidden,
+		key:     http.StatusOK,
+	} {
+		if code := do(k); code != want {
+			t.Errorf("request with key %q = %d, want %d", k, code, want)
+		}
+	}
+}
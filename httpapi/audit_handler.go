@@ -0,0 +1,62 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/korylprince/bisd-device-checkout-server/api"
+)
+
+// defaultAuditLimit is the maximum number of audit events returned if no limit is given
+const defaultAuditLimit = 500
+
+// GET /audit[?other_id=&bag_tag=&username=&from=YYYY-MM-DD&to=YYYY-MM-DD&limit=]
+func handleReadAuditEvents(_ http.ResponseWriter, r *http.Request) *handlerResponse {
+	q := r.URL.Query()
+
+	filter := &api.AuditFilter{
+		OtherID:  q.Get("other_id"),
+		BagTag:   q.Get("bag_tag"),
+		Username: q.Get("username"),
+		Limit:    defaultAuditLimit,
+	}
+
+	if from := q.Get("from"); from != "" {
+		date, err := time.ParseInLocation("2006-01-02", from, time.Local)
+		if err != nil {
+			return handleError(http.StatusBadRequest, fmt.Errorf("Could not parse from date (expected YYYY-MM-DD): %v", err))
+		}
+		filter.From = &date
+	}
+
+	if to := q.Get("to"); to != "" {
+		date, err := time.ParseInLocation("2006-01-02", to, time.Local)
+		if err != nil {
+			return handleError(http.StatusBadRequest, fmt.Errorf("Could not parse to date (expected YYYY-MM-DD): %v", err))
+		}
+		//include the whole day
+		date = date.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		filter.To = &date
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return handleError(http.StatusBadRequest, fmt.Errorf("Invalid limit: %s", limit))
+		}
+		filter.Limit = n
+	}
+
+	events, err := api.GetAuditEvents(r.Context(), filter)
+	if resp := checkAPIError(err); resp != nil {
+		return resp
+	}
+
+	if events == nil {
+		events = make([]*api.AuditEvent, 0)
+	}
+
+	return &handlerResponse{Code: http.StatusOK, Body: events}
+}
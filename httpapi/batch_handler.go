@@ -0,0 +1,62 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/korylprince/bisd-device-checkout-server/api"
+)
+
+// batch modes
+const (
+	batchModeAllOrNothing = "all_or_nothing"
+	batchModeBestEffort   = "best_effort"
+)
+
+// POST /checkouts/batch
+func handleBatchCheckout(_ http.ResponseWriter, r *http.Request) *handlerResponse {
+	type request struct {
+		Mode      string               `json:"mode,omitempty"`
+		Checkouts []*api.BatchCheckout `json:"checkouts"`
+	}
+
+	type response struct {
+		Status  string             `json:"status"`
+		Error   string             `json:"error,omitempty"`
+		Results []*api.BatchResult `json:"results"`
+	}
+
+	var req *request
+	d := json.NewDecoder(r.Body)
+
+	err := d.Decode(&req)
+	if err != nil || req == nil {
+		return handleError(http.StatusBadRequest, fmt.Errorf("Could not decode json: %v", err))
+	}
+
+	var bestEffort bool
+	switch req.Mode {
+	case "", batchModeAllOrNothing:
+	case batchModeBestEffort:
+		bestEffort = true
+	default:
+		return handleError(http.StatusBadRequest, fmt.Errorf("Invalid mode: %s", req.Mode))
+	}
+
+	results, err := api.CheckoutDevices(r.Context(), req.Checkouts, bestEffort)
+	if err != nil && results == nil {
+		return checkAPIError(err)
+	}
+
+	//all or nothing batch failed; return results so caller can see which checkouts failed
+	if err != nil {
+		return &handlerResponse{
+			Code: http.StatusBadRequest,
+			Body: &response{Status: api.BatchStatusError, Error: err.Error(), Results: results},
+			Err:  err,
+		}
+	}
+
+	return &handlerResponse{Code: http.StatusOK, Body: &response{Status: api.BatchStatusOK, Results: results}}
+}
@@ -0,0 +1,51 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/korylprince/bisd-device-checkout-server/api"
+)
+
+// POST /charges/:id/payments
+func handleCreatePayment(_ http.ResponseWriter, r *http.Request) *handlerResponse {
+	type request struct {
+		OtherID       string  `json:"other_id"`
+		Amount        float32 `json:"amount"`
+		Method        string  `json:"method"`
+		ReceiptNumber string  `json:"receipt_number,omitempty"`
+	}
+
+	type response struct {
+		Payment *api.Payment `json:"payment"`
+		Status  *api.Status  `json:"status"`
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		return handleError(http.StatusBadRequest, fmt.Errorf("Could not parse charge id: %v", err))
+	}
+
+	var req *request
+	d := json.NewDecoder(r.Body)
+
+	err = d.Decode(&req)
+	if err != nil || req == nil {
+		return handleError(http.StatusBadRequest, fmt.Errorf("Could not decode json: %v", err))
+	}
+
+	if req.OtherID == "" {
+		return handleError(http.StatusBadRequest, errors.New("other_id empty"))
+	}
+
+	payment, status, err := api.RecordPayment(r.Context(), id, req.OtherID, req.Amount, req.Method, req.ReceiptNumber)
+	if resp := checkAPIError(err); resp != nil {
+		return resp
+	}
+
+	return &handlerResponse{Code: http.StatusOK, Body: &response{Payment: payment, Status: status}}
+}
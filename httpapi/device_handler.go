@@ -2,6 +2,7 @@ package httpapi
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -26,10 +27,112 @@ func handleCheckoutDevice(_ http.ResponseWriter, r *http.Request) *handlerRespon
 		return handleError(http.StatusBadRequest, fmt.Errorf("Could not decode json: %v", err))
 	}
 
-	err = api.CheckoutDevice(r.Context(), otherID, bagTag, req.Note)
+	id, err := api.CheckoutDevice(r.Context(), otherID, bagTag, req.Note)
+	if resp := checkAPIError(err); resp != nil {
+		return resp
+	}
+
+	return &handlerResponse{Code: http.StatusOK, Body: map[string]interface{}{"Status": "OK", "checkout_id": id}}
+}
+
+// POST /students/:otherID/devices/:bagTag/checkin
+func handleCheckinDevice(_ http.ResponseWriter, r *http.Request) *handlerResponse {
+	type request struct {
+		Damaged bool          `json:"damaged,omitempty"`
+		Damages []*api.Damage `json:"damages,omitempty"`
+		Note    string        `json:"note,omitempty"`
+	}
+
+	otherID := mux.Vars(r)["otherID"]
+	bagTag := mux.Vars(r)["bagTag"]
+
+	var req *request
+	d := json.NewDecoder(r.Body)
+
+	err := d.Decode(&req)
+	if err != nil || req == nil {
+		return handleError(http.StatusBadRequest, fmt.Errorf("Could not decode json: %v", err))
+	}
+
+	err = api.CheckinDevice(r.Context(), otherID, bagTag, req.Damaged, req.Damages, req.Note)
+	if resp := checkAPIError(err); resp != nil {
+		return resp
+	}
+
+	return &handlerResponse{Code: http.StatusOK, Body: map[string]string{"Status": "OK"}}
+}
+
+// POST /students/:otherID/devices/:bagTag/swap
+func handleSwapDevice(_ http.ResponseWriter, r *http.Request) *handlerResponse {
+	type request struct {
+		NewBagTag string `json:"new_bag_tag"`
+		Reason    string `json:"reason,omitempty"`
+	}
+
+	otherID := mux.Vars(r)["otherID"]
+	bagTag := mux.Vars(r)["bagTag"]
+
+	var req *request
+	d := json.NewDecoder(r.Body)
+
+	err := d.Decode(&req)
+	if err != nil || req == nil {
+		return handleError(http.StatusBadRequest, fmt.Errorf("Could not decode json: %v", err))
+	}
+
+	if req.NewBagTag == "" {
+		return handleError(http.StatusBadRequest, errors.New("new_bag_tag empty"))
+	}
+
+	err = api.SwapDevice(r.Context(), otherID, bagTag, req.NewBagTag, req.Reason)
+	if resp := checkAPIError(err); resp != nil {
+		return resp
+	}
+
+	return &handlerResponse{Code: http.StatusOK, Body: map[string]string{"Status": "OK"}}
+}
+
+// POST /students/:otherID/devices/:bagTag/undo
+func handleUndoCheckout(_ http.ResponseWriter, r *http.Request) *handlerResponse {
+	otherID := mux.Vars(r)["otherID"]
+	bagTag := mux.Vars(r)["bagTag"]
+
+	err := api.UndoCheckout(r.Context(), otherID, bagTag)
 	if resp := checkAPIError(err); resp != nil {
 		return resp
 	}
 
 	return &handlerResponse{Code: http.StatusOK, Body: map[string]string{"Status": "OK"}}
 }
+
+// GET /devices/:bagTag/history
+func handleReadDeviceHistory(_ http.ResponseWriter, r *http.Request) *handlerResponse {
+	bagTag := mux.Vars(r)["bagTag"]
+
+	history, err := api.GetDeviceHistory(r.Context(), bagTag)
+	if resp := checkAPIError(err); resp != nil {
+		return resp
+	}
+
+	if history == nil {
+		history = make([]*api.HistoryEntry, 0)
+	}
+
+	return &handlerResponse{Code: http.StatusOK, Body: history}
+}
+
+// GET /devices?serial=...|inventory=...|bag_tag=...
+func handleReadDevice(_ http.ResponseWriter, r *http.Request) *handlerResponse {
+	q := r.URL.Query()
+
+	device, err := api.LookupDevice(r.Context(), q.Get("serial"), q.Get("inventory"), q.Get("bag_tag"))
+	if resp := checkAPIError(err); resp != nil {
+		return resp
+	}
+
+	if device == nil {
+		return handleError(http.StatusNotFound, errors.New("Could not find device"))
+	}
+
+	return &handlerResponse{Code: http.StatusOK, Body: device}
+}
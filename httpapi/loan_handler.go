@@ -0,0 +1,70 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/korylprince/bisd-device-checkout-server/api"
+)
+
+// POST /students/:otherID/devices/:bagTag/loan
+func handleCheckoutLoaner(_ http.ResponseWriter, r *http.Request) *handlerResponse {
+	type request struct {
+		Due  string `json:"due"`
+		Note string `json:"note,omitempty"`
+	}
+
+	otherID := mux.Vars(r)["otherID"]
+	bagTag := mux.Vars(r)["bagTag"]
+
+	var req *request
+	d := json.NewDecoder(r.Body)
+
+	err := d.Decode(&req)
+	if err != nil || req == nil {
+		return handleError(http.StatusBadRequest, fmt.Errorf("Could not decode json: %v", err))
+	}
+
+	if req.Due == "" {
+		return handleError(http.StatusBadRequest, errors.New("due empty"))
+	}
+
+	due, err := time.ParseInLocation("2006-01-02", req.Due, time.Local)
+	if err != nil {
+		return handleError(http.StatusBadRequest, fmt.Errorf("Could not parse due date (expected YYYY-MM-DD): %v", err))
+	}
+
+	//loaner is due at the end of the day
+	due = due.AddDate(0, 0, 1).Add(-time.Second)
+
+	err = api.CheckoutLoaner(r.Context(), otherID, bagTag, due, req.Note)
+	if resp := checkAPIError(err); resp != nil {
+		return resp
+	}
+
+	return &handlerResponse{Code: http.StatusOK, Body: map[string]string{"Status": "OK"}}
+}
+
+// GET /loans[?overdue=true]
+func handleReadLoanList(_ http.ResponseWriter, r *http.Request) *handlerResponse {
+	type loan struct {
+		*api.Loan
+		Overdue bool `json:"overdue"`
+	}
+
+	loans, err := api.GetLoanList(r.Context(), r.URL.Query().Get("overdue") == "true")
+	if resp := checkAPIError(err); resp != nil {
+		return resp
+	}
+
+	list := make([]*loan, 0, len(loans))
+	for _, l := range loans {
+		list = append(list, &loan{Loan: l, Overdue: l.Overdue()})
+	}
+
+	return &handlerResponse{Code: http.StatusOK, Body: list}
+}
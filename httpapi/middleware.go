@@ -2,7 +2,6 @@ package httpapi
 
 import (
 	"context"
-	"crypto/subtle"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -18,25 +17,34 @@ import (
 )
 
 type handlerResponse struct {
-	Code int
-	Body interface{}
-	User *api.User
-	Err  error
+	Code      int
+	Body      interface{}
+	User      *api.User
+	RequestID string
+	Err       error
+}
+
+// fileResponse is a handlerResponse Body that is written as a file instead of being encoded as JSON
+type fileResponse struct {
+	ContentType string
+	Filename    string
+	Data        []byte
 }
 
 type returnHandler func(http.ResponseWriter, *http.Request) *handlerResponse
 
-const logTemplate = "{{.Date}} {{.Method}} {{.Path}}{{if .Query}}?{{.Query}}{{end}} {{.Code}} ({{.Status}}){{if .User}}, User: {{.User.Username}}{{end}}{{if .Err}}, Error: {{.Err}}{{end}}\n"
+const logTemplate = "{{.Date}} {{.Method}} {{.Path}}{{if .Query}}?{{.Query}}{{end}} {{.Code}} ({{.Status}}){{if .RequestID}}, Request: {{.RequestID}}{{end}}{{if .User}}, User: {{.User.Username}}{{end}}{{if .Err}}, Error: {{.Err}}{{end}}\n"
 
 type logData struct {
-	Date   string
-	User   *api.User
-	Status string
-	Code   int
-	Method string
-	Path   string
-	Query  string
-	Err    error
+	Date      string
+	User      *api.User
+	RequestID string
+	Status    string
+	Code      int
+	Method    string
+	Path      string
+	Query     string
+	Err       error
 }
 
 func logMiddleware(next returnHandler, writer io.Writer) http.Handler {
@@ -44,14 +52,15 @@ func logMiddleware(next returnHandler, writer io.Writer) http.Handler {
 		resp := next(w, r)
 
 		err := template.Must(template.New("log").Parse(logTemplate)).Execute(writer, &logData{
-			Date:   time.Now().Format("2006-01-02:15:04:05 -0700"),
-			User:   resp.User,
-			Status: http.StatusText(resp.Code),
-			Code:   resp.Code,
-			Method: r.Method,
-			Path:   r.URL.Path,
-			Query:  r.URL.RawQuery,
-			Err:    resp.Err,
+			Date:      time.Now().Format("2006-01-02:15:04:05 -0700"),
+			User:      resp.User,
+			RequestID: resp.RequestID,
+			Status:    http.StatusText(resp.Code),
+			Code:      resp.Code,
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Query:     r.URL.RawQuery,
+			Err:       resp.Err,
 		})
 
 		if err != nil {
@@ -60,11 +69,27 @@ func logMiddleware(next returnHandler, writer io.Writer) http.Handler {
 	})
 }
 
+// requestIDMiddleware assigns a random ID to the request, which is returned in the X-Request-ID header
+// and recorded with any audit events for the request
+func requestIDMiddleware(next returnHandler) returnHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlerResponse {
+		id := randString(16)
+		w.Header().Set("X-Request-ID", id)
+
+		ctx := context.WithValue(r.Context(), api.RequestIDKey, id)
+		resp := next(w, r.WithContext(ctx))
+		resp.RequestID = id
+
+		return resp
+	}
+}
+
 func jsonMiddleware(next returnHandler) returnHandler {
 	return func(w http.ResponseWriter, r *http.Request) *handlerResponse {
 		var resp *handlerResponse
 
-		if r.Method != "GET" {
+		//GET and DELETE requests don't have a body
+		if r.Method != "GET" && r.Method != "DELETE" {
 			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
 			if err != nil {
 				resp = handleError(http.StatusBadRequest, errors.New("Could not parse Content-Type"))
@@ -79,6 +104,16 @@ func jsonMiddleware(next returnHandler) returnHandler {
 		w.Header().Set("Content-Type", "application/json")
 		resp = next(w, r)
 
+		if f, ok := resp.Body.(*fileResponse); ok {
+			w.Header().Set("Content-Type", f.ContentType)
+			w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, f.Filename))
+			w.WriteHeader(resp.Code)
+			if _, err := w.Write(f.Data); err != nil {
+				return handleError(http.StatusInternalServerError, fmt.Errorf("Could not write file: %v", err))
+			}
+			return resp
+		}
+
 	serve:
 		w.WriteHeader(resp.Code)
 		e := json.NewEncoder(w)
@@ -90,19 +125,28 @@ func jsonMiddleware(next returnHandler) returnHandler {
 	}
 }
 
+// sessionID returns the session id from the request's Authorization header,
+// or a handlerResponse if the header is missing or invalid
+func sessionID(r *http.Request) (string, *handlerResponse) {
+	auth := r.Header.Get("Authorization")
+	if auth == "" {
+		return "", handleError(http.StatusUnauthorized, errors.New("No Authorization header"))
+	}
+
+	if !strings.HasPrefix(auth, `Session id="`) || len(auth) < 13 {
+		return "", handleError(http.StatusBadRequest, errors.New("Invalid Authorization header"))
+	}
+
+	return auth[12 : len(auth)-1], nil
+}
+
 func authMiddleware(next returnHandler, s SessionStore) returnHandler {
 	return func(w http.ResponseWriter, r *http.Request) *handlerResponse {
-		auth := r.Header.Get("Authorization")
-		if auth == "" {
-			return handleError(http.StatusUnauthorized, errors.New("No Authorization header"))
-		}
-
-		if !strings.HasPrefix(auth, `Session id="`) || len(auth) < 13 {
-			return handleError(http.StatusBadRequest, errors.New("Invalid Authorization header"))
+		id, resp := sessionID(r)
+		if resp != nil {
+			return resp
 		}
 
-		id := auth[12 : len(auth)-1]
-
 		sess, err := s.Check(id)
 		if err != nil {
 			return handleError(http.StatusInternalServerError, fmt.Errorf("Could not check session key: %v", err))
@@ -112,33 +156,66 @@ func authMiddleware(next returnHandler, s SessionStore) returnHandler {
 		}
 
 		ctx := context.WithValue(r.Context(), api.UserKey, sess.User)
-		resp := next(w, r.WithContext(ctx))
+		resp = next(w, r.WithContext(ctx))
 		resp.User = sess.User
 
 		return resp
 	}
 }
 
-func authKeyMiddleware(next returnHandler, key string) returnHandler {
-	keybuf := []byte("Bearer " + key)
-	keylen := int32(len(keybuf))
+// roleMiddleware only allows users with the given role. It must be used inside authMiddleware
+func roleMiddleware(next returnHandler, role api.Role) returnHandler {
 	return func(w http.ResponseWriter, r *http.Request) *handlerResponse {
-		if keylen == 0 {
+		if user, ok := r.Context().Value(api.UserKey).(*api.User); !ok || !user.HasRole(role) {
+			return handleError(http.StatusForbidden, fmt.Errorf("User does not have the %s role", role))
+		}
+		return next(w, r)
+	}
+}
+
+// authKeyMiddleware only allows requests with an API key that has the given scope.
+// If no API keys are configured, the routes aren't found. The key's name is logged as the user
+func authKeyMiddleware(next returnHandler, keys *APIKeyStore, scope string) returnHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlerResponse {
+		configured, err := keys.Configured()
+		if err != nil {
+			return handleError(http.StatusInternalServerError, fmt.Errorf("Could not check API keys: %v", err))
+		}
+		if !configured {
 			return notFoundHandler(w, r)
 		}
+
 		auth := r.Header.Get("Authorization")
 		if auth == "" {
 			return handleError(http.StatusUnauthorized, errors.New("No Authorization header"))
 		}
 
-		authbuf := []byte(auth)
-		if subtle.ConstantTimeEq(keylen, int32(len(authbuf))) != 1 || subtle.ConstantTimeCompare(keybuf, authbuf) != 1 {
+		if !strings.HasPrefix(auth, "Bearer ") || len(auth) < 8 {
+			return handleError(http.StatusBadRequest, errors.New("Invalid Authorization header"))
+		}
+
+		key, err := keys.Check(auth[7:])
+		if err != nil {
+			return handleError(http.StatusInternalServerError, fmt.Errorf("Could not check API key: %v", err))
+		}
+		if key == nil {
 			return handleError(http.StatusUnauthorized, errors.New("Invalid Authorization"))
 		}
-		return next(w, r)
+
+		var resp *handlerResponse
+		if key.HasScope(scope) {
+			resp = next(w, r)
+		} else {
+			resp = handleError(http.StatusForbidden, fmt.Errorf("API key does not have the %s scope", scope))
+		}
+		resp.User = &api.User{Username: "key:" + key.Name, DisplayName: key.Name}
+
+		return resp
 	}
 }
 
+// txMiddleware begins inventory and skyward transactions for the request, committing them if the request succeeds.
+// If skywardDB is nil, no skyward transaction is created
 func txMiddleware(next returnHandler, inventoryDB, skywardDB *sql.DB) returnHandler {
 	return func(w http.ResponseWriter, r *http.Request) *handlerResponse {
 		//create inventory tx
@@ -149,25 +226,43 @@ func txMiddleware(next returnHandler, inventoryDB, skywardDB *sql.DB) returnHand
 		ctx := context.WithValue(r.Context(), api.InventoryTransactionKey, itx)
 
 		//create skyward tx
-		stx, err := skywardDB.Begin()
-		if err != nil {
-			return handleError(http.StatusInternalServerError, fmt.Errorf("Could not begin Skyward transaction: %v", err))
+		var stx *sql.Tx
+		if skywardDB != nil {
+			stx, err = skywardDB.Begin()
+			if err != nil {
+				return handleError(http.StatusInternalServerError, fmt.Errorf("Could not begin Skyward transaction: %v", err))
+			}
+			ctx = context.WithValue(ctx, api.SkywardTransactionKey, stx)
 		}
-		ctx = context.WithValue(ctx, api.SkywardTransactionKey, stx)
 
 		resp := next(w, r.WithContext(ctx))
 
-		//commit skyward tx
-		if err = stx.Commit(); err != nil {
-			//rollback skyward tx
-			if rErr := stx.Rollback(); rErr != nil && rErr != sql.ErrTxDone {
-				return handleError(http.StatusInternalServerError, fmt.Errorf("Could not rollback Skyward transaction: %v", rErr))
+		//rollback on error so partial changes aren't committed
+		if resp.Code >= http.StatusBadRequest {
+			if stx != nil {
+				if rErr := stx.Rollback(); rErr != nil && rErr != sql.ErrTxDone {
+					return handleError(http.StatusInternalServerError, fmt.Errorf("Could not rollback Skyward transaction: %v", rErr))
+				}
 			}
-			//rollback inventory tx
 			if rErr := itx.Rollback(); rErr != nil && rErr != sql.ErrTxDone {
 				return handleError(http.StatusInternalServerError, fmt.Errorf("Could not rollback Inventory transaction: %v", rErr))
 			}
-			return handleError(http.StatusInternalServerError, fmt.Errorf("Could not commit Skyward transaction: %v", err))
+			return resp
+		}
+
+		//commit skyward tx
+		if stx != nil {
+			if err = stx.Commit(); err != nil {
+				//rollback skyward tx
+				if rErr := stx.Rollback(); rErr != nil && rErr != sql.ErrTxDone {
+					return handleError(http.StatusInternalServerError, fmt.Errorf("Could not rollback Skyward transaction: %v", rErr))
+				}
+				//rollback inventory tx
+				if rErr := itx.Rollback(); rErr != nil && rErr != sql.ErrTxDone {
+					return handleError(http.StatusInternalServerError, fmt.Errorf("Could not rollback Inventory transaction: %v", rErr))
+				}
+				return handleError(http.StatusInternalServerError, fmt.Errorf("Could not commit Skyward transaction: %v", err))
+			}
 		}
 
 		//commit inventory tx
@@ -0,0 +1,67 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/korylprince/bisd-device-checkout-server/api"
+)
+
+func TestRoleMiddleware(t *testing.T) {
+	db := testDB(t)
+	s := NewSQLSessionStore(db, time.Hour)
+
+	next := func(w http.ResponseWriter, r *http.Request) *handlerResponse {
+		return &handlerResponse{Code: http.StatusOK}
+	}
+
+	do := func(id string, role api.Role) int {
+		r := httptest.NewRequest("GET", "/api/1.4/audit", nil)
+		r.Header.Set("Authorization", fmt.Sprintf(`Session id="%s"`, id))
+		return authMiddleware(roleMiddleware(next, role), s)(httptest.NewRecorder(), r).Code
+	}
+
+	sessions := make(map[api.Role]string)
+	for _, role := range []api.Role{api.RoleViewer, api.RoleCheckout, api.RoleCashier, api.RoleAdmin} {
+		id, err := s.Create(&api.User{Username: string(role), DisplayName: string(role), Roles: []api.Role{role}})
+		if err != nil {
+			t.Fatal("Create returned an error:", err)
+		}
+		sessions[role] = id
+	}
+
+	for _, test := range []struct {
+		user, route api.Role
+		want        int
+	}{
+		{api.RoleViewer, api.RoleViewer, http.StatusOK},
+		{api.RoleViewer, api.RoleCheckout, http.StatusForbidden},
+		{api.RoleViewer, api.RoleAdmin, http.StatusForbidden},
+		{api.RoleCheckout, api.RoleViewer, http.StatusOK},
+		{api.RoleCheckout, api.RoleCheckout, http.StatusOK},
+		{api.RoleCheckout, api.RoleCashier, http.StatusForbidden},
+		{api.RoleCashier, api.RoleCashier, http.StatusOK},
+		{api.RoleCashier, api.RoleCheckout, http.StatusForbidden},
+		{api.RoleCashier, api.RoleAdmin, http.StatusForbidden},
+		{api.RoleAdmin, api.RoleCheckout, http.StatusOK},
+		{api.RoleAdmin, api.RoleCashier, http.StatusOK},
+		{api.RoleAdmin, api.RoleAdmin, http.StatusOK},
+	} {
+		if code := do(sessions[test.user], test.route); code != test.want {
+			t.Errorf("%s user on %s route = %d, want %d", test.user, test.route, code, test.want)
+		}
+	}
+
+	//a session stored before roles were added must log in again
+	if _, err := db.Exec("INSERT INTO sessions(id, username, user, expires) VALUES (?, 'old', ?, ?);",
+		hashSessionID("old"), `{"Username":"old","DisplayName":"Old User"}`, time.Now().Add(time.Hour),
+	); err != nil {
+		t.Fatal("Could not insert session:", err)
+	}
+	if code := do("old", api.RoleViewer); code != http.StatusUnauthorized {
+		t.Errorf("session without roles = %d, want %d", code, http.StatusUnauthorized)
+	}
+}
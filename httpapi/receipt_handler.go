@@ -0,0 +1,137 @@
+package httpapi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"text/template"
+
+	"github.com/gorilla/mux"
+	"github.com/korylprince/bisd-device-checkout-server/api"
+	"github.com/korylprince/bisd-device-checkout-server/pdf"
+)
+
+// receipt templates are rendered to text, then written as a PDF. Lines beginning with "# " are headings
+const checkoutReceiptTemplate = `# Bullard ISD Chromebook Checkout
+Checkout #{{.ID}}
+{{.Date.Local.Format "01/02/2006 3:04 PM"}}
+
+Student:        {{.Name}} ({{.OtherID}})
+Bag:            {{bag .StatusType}}
+Bag Tag:        {{.BagTag}}
+Serial Number:  {{.SerialNumber}}
+{{- if .Model}}
+Model:          {{.Model}}
+{{- end}}
+
+Checked out by: {{.DisplayName}}
+
+The student named above has received the Chromebook listed above and agrees to follow the Bullard ISD Chromebook guidelines while it is checked out.
+
+
+Student Signature: ______________________________  Date: __________
+
+
+Parent/Guardian Signature: ______________________  Date: __________
+`
+
+const paymentReceiptTemplate = `# Bullard ISD Chromebook Payment Receipt
+Payment #{{.ID}}
+{{.Date.Local.Format "01/02/2006 3:04 PM"}}
+
+Student:        {{.Name}} ({{.OtherID}})
+{{- if .BagTag}}
+Bag Tag:        {{.BagTag}}
+Serial Number:  {{.SerialNumber}}
+{{- end}}
+{{- if .StatusType}}
+Bag:            {{bag .StatusType}}
+{{- end}}
+
+Charges:
+{{- range .Charges}}
+    {{.Description}}: {{money .Amount}}
+{{- end}}
+    Total: {{money .Charged}}
+
+Amount Paid:    {{money .Amount}} ({{.Method}}{{if .ReceiptNumber}}, Receipt {{.ReceiptNumber}}{{end}})
+
+Received by:    {{.DisplayName}}
+`
+
+var receiptFuncs = template.FuncMap{
+	"bag": func(t api.StatusType) string {
+		switch t {
+		case api.StatusTypeBlackBag:
+			return "Black Bag"
+		case api.StatusTypeRedBag:
+			return "Red Bag"
+		case api.StatusTypeClassroomOnly:
+			return "Classroom Only"
+		}
+		return "None"
+	},
+	"money": func(amount float32) string {
+		return fmt.Sprintf("$%.2f", amount)
+	},
+}
+
+var (
+	checkoutReceipt = template.Must(template.New("checkout").Funcs(receiptFuncs).Parse(checkoutReceiptTemplate))
+	paymentReceipt  = template.Must(template.New("payment").Funcs(receiptFuncs).Parse(paymentReceiptTemplate))
+)
+
+// renderReceipt returns a handlerResponse with the PDF rendered from t and receipt
+func renderReceipt(t *template.Template, receipt *api.Receipt, filename string) *handlerResponse {
+	text := new(bytes.Buffer)
+	if err := t.Execute(text, receipt); err != nil {
+		return handleError(http.StatusInternalServerError, fmt.Errorf("Could not render receipt: %v", err))
+	}
+
+	buf := new(bytes.Buffer)
+	if err := pdf.Write(buf, text.String()); err != nil {
+		return handleError(http.StatusInternalServerError, fmt.Errorf("Could not write receipt: %v", err))
+	}
+
+	return &handlerResponse{Code: http.StatusOK, Body: &fileResponse{ContentType: "application/pdf", Filename: filename, Data: buf.Bytes()}}
+}
+
+// GET /checkouts/:id/receipt.pdf
+func handleReadCheckoutReceipt(_ http.ResponseWriter, r *http.Request) *handlerResponse {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		return handleError(http.StatusBadRequest, fmt.Errorf("Could not parse checkout id: %v", err))
+	}
+
+	receipt, err := api.GetCheckoutReceipt(r.Context(), id)
+	if resp := checkAPIError(err); resp != nil {
+		return resp
+	}
+
+	if receipt == nil {
+		return handleError(http.StatusNotFound, errors.New("Could not find checkout"))
+	}
+
+	return renderReceipt(checkoutReceipt, receipt, fmt.Sprintf("checkout-%d.pdf", id))
+}
+
+// GET /payments/:id/receipt.pdf
+func handleReadPaymentReceipt(_ http.ResponseWriter, r *http.Request) *handlerResponse {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		return handleError(http.StatusBadRequest, fmt.Errorf("Could not parse payment id: %v", err))
+	}
+
+	receipt, err := api.GetPaymentReceipt(r.Context(), id)
+	if resp := checkAPIError(err); resp != nil {
+		return resp
+	}
+
+	if receipt == nil {
+		return handleError(http.StatusNotFound, errors.New("Could not find payment"))
+	}
+
+	return renderReceipt(paymentReceipt, receipt, fmt.Sprintf("payment-%d.pdf", id))
+}
@@ -0,0 +1,58 @@
+package httpapi
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/korylprince/bisd-device-checkout-server/api"
+)
+
+func TestReceiptTemplates(t *testing.T) {
+	date := time.Date(2026, 8, 14, 15, 4, 0, 0, time.Local)
+
+	for _, test := range []struct {
+		name    string
+		receipt *api.Receipt
+		want    []string
+	}{
+		{
+			name: "checkout",
+			receipt: &api.Receipt{ID: 12, Date: date, OtherID: "100001", Name: "Jane Doe", BagTag: "1001",
+				SerialNumber: "SER1", Model: "Chromebook", StatusType: api.StatusTypeRedBag, DisplayName: "Tech Person"},
+			want: []string{"Checkout #12", "08/14/2026 3:04 PM", "Jane Doe (100001)", "Red Bag", "SER1", "Model:          Chromebook", "Checked out by: Tech Person"},
+		},
+		{
+			name: "payment",
+			receipt: &api.Receipt{ID: 7, Date: date, OtherID: "100001", Name: "Jane Doe", BagTag: "1001", SerialNumber: "SER1",
+				StatusType: api.StatusTypeBlackBag, DisplayName: "Clerk Person", Charges: []*api.Damage{{Description: "Screen", Amount: 45}},
+				Charged: 45, Amount: 20.5, Method: api.PaymentMethodCheck, ReceiptNumber: "R-123"},
+			want: []string{"Payment #7", "Black Bag", "Screen: $45.00", "Total: $45.00", "$20.50 (check, Receipt R-123)", "Received by:    Clerk Person"},
+		},
+	} {
+		tmpl := checkoutReceipt
+		if test.name == "payment" {
+			tmpl = paymentReceipt
+		}
+
+		text := new(bytes.Buffer)
+		if err := tmpl.Execute(text, test.receipt); err != nil {
+			t.Fatalf("%s: Execute returned an error: %v", test.name, err)
+		}
+		for _, want := range test.want {
+			if !strings.Contains(text.String(), want) {
+				t.Errorf("%s: receipt doesn't contain %q:\n%s", test.name, want, text)
+			}
+		}
+
+		resp := renderReceipt(tmpl, test.receipt, test.name+".pdf")
+		f, ok := resp.Body.(*fileResponse)
+		if !ok {
+			t.Fatalf("%s: renderReceipt = %+v, want a file", test.name, resp)
+		}
+		if f.ContentType != "application/pdf" || f.Filename != test.name+".pdf" || !bytes.HasPrefix(f.Data, []byte("%PDF-")) {
+			t.Errorf("%s: renderReceipt = %s %s, want a PDF", test.name, f.ContentType, f.Filename)
+		}
+	}
+}
@@ -10,30 +10,54 @@ import (
 )
 
 // NewRouter returns an HTTP router for the HTTP API
-func NewRouter(w io.Writer, config *api.AuthConfig, apikey string, s SessionStore, inventoryDB, skywardDB *sql.DB) http.Handler {
+func NewRouter(w io.Writer, config *api.AuthConfig, keys *APIKeyStore, s SessionStore, inventoryDB, skywardDB *sql.DB) http.Handler {
 
-	//construct middleware
-	var m = func(h returnHandler) http.Handler {
-		return logMiddleware(jsonMiddleware(txMiddleware(authMiddleware(h, s), inventoryDB, skywardDB)), w)
+	//construct middleware. Authentication is checked before the database transactions begin,
+	//since sessions may be stored in the inventory database. The user must have role to access the route
+	var m = func(h returnHandler, role api.Role) http.Handler {
+		return logMiddleware(requestIDMiddleware(jsonMiddleware(authMiddleware(roleMiddleware(txMiddleware(h, inventoryDB, skywardDB), role), s))), w)
 	}
-	var mk = func(h returnHandler) http.Handler {
-		return logMiddleware(jsonMiddleware(txMiddleware(authKeyMiddleware(h, apikey), inventoryDB, skywardDB)), w)
+	var mk = func(h returnHandler, scope string) http.Handler {
+		return logMiddleware(requestIDMiddleware(jsonMiddleware(authKeyMiddleware(txMiddleware(h, inventoryDB, skywardDB), keys, scope))), w)
 	}
 
 	r := mux.NewRouter()
 
-	r.Path("/students").Queries("status", "true").Methods("GET").Handler(m(handleReadStudentStatuses))
-	r.Path("/students").Methods("GET").Handler(m(handleReadStudentList))
-	r.Path("/students/{otherID:[0-9]{6}}/status").Methods("GET").Handler(m(handleReadStudentStatus))
-	r.Path("/students/{otherID:[0-9]{6}}/devices/{bagTag:[0-9]{4}}").Methods("POST").Handler(m(handleCheckoutDevice))
+	r.Path("/students").Queries("status", "true").Methods("GET").Handler(m(handleReadStudentStatuses, api.RoleViewer))
+	r.Path("/students").Methods("GET").Handler(m(handleReadStudentList, api.RoleViewer))
+	r.Path("/students/{otherID:[0-9]{6}}").Methods("GET").Handler(m(handleReadStudent, api.RoleViewer))
+	r.Path("/students/{otherID:[0-9]{6}}/status").Methods("GET").Handler(m(handleReadStudentStatus, api.RoleViewer))
+	r.Path("/students/{otherID:[0-9]{6}}/status/explain").Methods("GET").Handler(m(handleExplainStudentStatus, api.RoleCheckout))
+	r.Path("/students/{otherID:[0-9]{6}}/devices/{bagTag}").Methods("POST").Handler(m(handleCheckoutDevice, api.RoleCheckout))
+	r.Path("/students/{otherID:[0-9]{6}}/devices/{bagTag:[0-9]{4}}/checkin").Methods("POST").Handler(m(handleCheckinDevice, api.RoleCheckout))
+	r.Path("/students/{otherID:[0-9]{6}}/devices/{bagTag:[0-9]{4}}/swap").Methods("POST").Handler(m(handleSwapDevice, api.RoleCheckout))
+	r.Path("/students/{otherID:[0-9]{6}}/devices/{bagTag:[0-9]{4}}/undo").Methods("POST").Handler(m(handleUndoCheckout, api.RoleCheckout))
+	r.Path("/students/{otherID:[0-9]{6}}/devices/{bagTag:[0-9]{4}}/loan").Methods("POST").Handler(m(handleCheckoutLoaner, api.RoleCheckout))
 
-	r.Path("/auth").Methods("POST").Handler(logMiddleware(jsonMiddleware(txMiddleware(handleAuthenticate(config, s), inventoryDB, skywardDB)), w))
+	r.Path("/devices").Methods("GET").Handler(m(handleReadDevice, api.RoleViewer))
+	r.Path("/devices/{bagTag:[0-9]{4}}/history").Methods("GET").Handler(m(handleReadDeviceHistory, api.RoleViewer))
 
-	r.Path("/nosession/students").Queries("status", "true").Methods("GET").Handler(mk(handleReadStudentStatuses))
-	r.Path("/nosession/students").Methods("GET").Handler(mk(handleReadStudentList))
-	r.Path("/nosession/students/{otherID:[0-9]{6}}/status").Methods("GET").Handler(mk(handleReadStudentStatus))
+	r.Path("/charges/{id:[0-9]+}/payments").Methods("POST").Handler(m(handleCreatePayment, api.RoleCashier))
 
-	r.NotFoundHandler = m(notFoundHandler)
+	r.Path("/checkouts/batch").Methods("POST").Handler(m(handleBatchCheckout, api.RoleCheckout))
+	r.Path("/checkouts/{id:[0-9]+}/receipt.pdf").Methods("GET").Handler(m(handleReadCheckoutReceipt, api.RoleCheckout))
+
+	r.Path("/payments/{id:[0-9]+}/receipt.pdf").Methods("GET").Handler(m(handleReadPaymentReceipt, api.RoleCashier))
+
+	r.Path("/loans").Methods("GET").Handler(m(handleReadLoanList, api.RoleViewer))
+
+	r.Path("/audit").Methods("GET").Handler(m(handleReadAuditEvents, api.RoleAdmin))
+
+	//authentication doesn't use the database transactions, and sessions may be stored in the inventory database
+	r.Path("/auth").Methods("POST").Handler(logMiddleware(requestIDMiddleware(jsonMiddleware(handleAuthenticate(config, s))), w))
+	r.Path("/auth").Methods("DELETE").Handler(logMiddleware(requestIDMiddleware(jsonMiddleware(authMiddleware(handleLogout(s), s))), w))
+	r.Path("/sessions/{username}").Methods("DELETE").Handler(logMiddleware(requestIDMiddleware(jsonMiddleware(authMiddleware(roleMiddleware(handleDeleteUserSessions(s), api.RoleAdmin), s))), w))
+
+	r.Path("/nosession/students").Queries("status", "true").Methods("GET").Handler(mk(handleReadStudentStatuses, ScopeStudentStatuses))
+	r.Path("/nosession/students").Methods("GET").Handler(mk(handleReadStudentList, ScopeStudentList))
+	r.Path("/nosession/students/{otherID:[0-9]{6}}/status").Methods("GET").Handler(mk(handleReadStudentStatus, ScopeStudentStatus))
+
+	r.NotFoundHandler = m(notFoundHandler, api.RoleViewer)
 
 	return http.StripPrefix("/api/1.4", r)
 }
@@ -1,6 +1,7 @@
 package httpapi
 
 import (
+	"strings"
 	"sync"
 	"time"
 
@@ -17,6 +18,14 @@ type SessionStore interface {
 	//If sessionID is not valid, session will be nil.
 	//If the backend malfunctions, session will be nil and err will be non-nil.
 	Check(sessionID string) (session *Session, err error)
+
+	//Delete removes sessionID if it exists.
+	//If the backend malfunctions, err will be non-nil.
+	Delete(sessionID string) error
+
+	//DeleteUser removes all sessions for the user with the given username.
+	//If the backend malfunctions, err will be non-nil.
+	DeleteUser(username string) error
 }
 
 // Session represents a login session
@@ -84,3 +93,23 @@ func (m *MemorySessionStore) Check(sessionID string) (session *Session, err erro
 	}
 	return nil, nil
 }
+
+// Delete removes sessionID if it exists. err will always be nil.
+func (m *MemorySessionStore) Delete(sessionID string) error {
+	m.mu.Lock()
+	delete(m.store, sessionID)
+	m.mu.Unlock()
+	return nil
+}
+
+// DeleteUser removes all sessions for the user with the given username. err will always be nil.
+func (m *MemorySessionStore) DeleteUser(username string) error {
+	m.mu.Lock()
+	for id, s := range m.store {
+		if strings.EqualFold(s.User.Username, username) {
+			delete(m.store, id)
+		}
+	}
+	m.mu.Unlock()
+	return nil
+}
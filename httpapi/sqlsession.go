@@ -0,0 +1,122 @@
+package httpapi
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/korylprince/bisd-device-checkout-server/api"
+)
+
+// SQLSessionStore represents a SessionStore that uses the sessions table in a database.
+// Only a hash of each sessionID is stored
+type SQLSessionStore struct {
+	db       *sql.DB
+	duration time.Duration
+}
+
+// hashSessionID returns the hash of sessionID stored in the database
+func hashSessionID(sessionID string) string {
+	hash := sha256.Sum256([]byte(sessionID))
+	return hex.EncodeToString(hash[:])
+}
+
+// scavengeSQL removes stale records every hour
+func scavengeSQL(s *SQLSessionStore) {
+	for {
+		time.Sleep(time.Hour)
+		if _, err := s.db.Exec("DELETE FROM sessions WHERE expires < ?;", time.Now()); err != nil {
+			log.Println("Could not remove expired sessions:", err)
+		}
+	}
+}
+
+// NewSQLSessionStore returns a new SQLSessionStore using db with the given expiration duration.
+func NewSQLSessionStore(db *sql.DB, duration time.Duration) *SQLSessionStore {
+	s := &SQLSessionStore{db: db, duration: duration}
+	go scavengeSQL(s)
+	return s
+}
+
+// Create returns a new sessionID with the given User.
+func (s *SQLSessionStore) Create(user *api.User) (sessionID string, err error) {
+	buf, err := json.Marshal(user)
+	if err != nil {
+		return "", fmt.Errorf("Could not encode user: %v", err)
+	}
+
+	id := randString(128)
+
+	_, err = s.db.Exec("INSERT INTO sessions(id, username, user, expires) VALUES (?, ?, ?, ?);",
+		hashSessionID(id), strings.ToLower(user.Username), string(buf), time.Now().Add(s.duration),
+	)
+	if err != nil {
+		return "", fmt.Errorf("Could not create session: %v", err)
+	}
+
+	return id, nil
+}
+
+// Check returns whether or not sessionID is a valid session. If sessionID is not valid, session will be nil.
+// Sessions whose user has no roles are invalid
+func (s *SQLSessionStore) Check(sessionID string) (session *Session, err error) {
+	hash := hashSessionID(sessionID)
+
+	var (
+		buf     string
+		expires time.Time
+	)
+
+	err = s.db.QueryRow("SELECT user, expires FROM sessions WHERE id = ?;", hash).Scan(&buf, &expires)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("Could not query session: %v", err)
+	}
+
+	user := new(api.User)
+	if err = json.Unmarshal([]byte(buf), user); err != nil {
+		return nil, fmt.Errorf("Could not decode user: %v", err)
+	}
+
+	now := time.Now()
+
+	//sessions created before roles were added have none, so they are treated as expired to force the user to log in again
+	if !expires.After(now) || len(user.Roles) == 0 {
+		if _, err = s.db.Exec("DELETE FROM sessions WHERE id = ?;", hash); err != nil {
+			return nil, fmt.Errorf("Could not remove expired session: %v", err)
+		}
+		return nil, nil
+	}
+
+	session = &Session{User: user, Expires: now.Add(s.duration)}
+
+	if _, err = s.db.Exec("UPDATE sessions SET expires = ? WHERE id = ?;", session.Expires, hash); err != nil {
+		return nil, fmt.Errorf("Could not update session: %v", err)
+	}
+
+	return session, nil
+}
+
+// Delete removes sessionID if it exists.
+func (s *SQLSessionStore) Delete(sessionID string) error {
+	if _, err := s.db.Exec("DELETE FROM sessions WHERE id = ?;", hashSessionID(sessionID)); err != nil {
+		return fmt.Errorf("Could not remove session: %v", err)
+	}
+	return nil
+}
+
+// DeleteUser removes all sessions for the user with the given username. Usernames are matched case insensitively.
+func (s *SQLSessionStore) DeleteUser(username string) error {
+	//usernames are stored lowercase since AD usernames are case insensitive
+	if _, err := s.db.Exec("DELETE FROM sessions WHERE username = ?;", strings.ToLower(username)); err != nil {
+		return fmt.Errorf("Could not remove sessions for %s: %v", username, err)
+	}
+	return nil
+}
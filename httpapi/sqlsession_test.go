@@ -0,0 +1,134 @@
+package httpapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/korylprince/bisd-device-checkout-server/api"
+)
+
+func TestSQLSessionStore(t *testing.T) {
+	db := testDB(t)
+
+	user := &api.User{Username: "Tech", DisplayName: "Tech Person", Roles: []api.Role{api.RoleCheckout, api.RoleCashier}}
+	id, err := NewSQLSessionStore(db, time.Hour).Create(user)
+	if err != nil {
+		t.Fatal("Create returned an error:", err)
+	}
+
+	//only the hash of the session id is stored
+	var n int
+	if err = db.QueryRow("SELECT COUNT(*) FROM sessions WHERE id = ?;", id).Scan(&n); err != nil || n != 0 {
+		t.Errorf("sessions with the raw id = %d, %v, want 0", n, err)
+	}
+
+	//sessions survive a restart
+	s := NewSQLSessionStore(db, time.Hour)
+
+	//Check extends the expiration
+	if _, err = db.Exec("UPDATE sessions SET expires = ? WHERE id = ?;", time.Now().Add(time.Minute), hashSessionID(id)); err != nil {
+		t.Fatal("Could not update session:", err)
+	}
+
+	session, err := s.Check(id)
+	if err != nil || session == nil {
+		t.Fatalf("Check = %v, %v, want a session", session, err)
+	}
+	if !reflect.DeepEqual(session.User, user) {
+		t.Errorf("Check user = %+v, want %+v", session.User, user)
+	}
+
+	var expires time.Time
+	if err = db.QueryRow("SELECT expires FROM sessions WHERE id = ?;", hashSessionID(id)).Scan(&expires); err != nil {
+		t.Fatal("Could not query session:", err)
+	}
+	if expires.Before(time.Now().Add(59*time.Minute)) || !expires.Equal(session.Expires) {
+		t.Errorf("expires after Check = %v, session expires %v, want an hour from now", expires, session.Expires)
+	}
+
+	if session, err = s.Check("missing"); err != nil || session != nil {
+		t.Errorf("Check of a missing session = %v, %v, want nil", session, err)
+	}
+
+	//expired sessions are removed
+	if _, err = db.Exec("UPDATE sessions SET expires = ? WHERE id = ?;", time.Now().Add(-time.Minute), hashSessionID(id)); err != nil {
+		t.Fatal("Could not update session:", err)
+	}
+	if session, err = s.Check(id); err != nil || session != nil {
+		t.Errorf("Check of an expired session = %v, %v, want nil", session, err)
+	}
+	if err = db.QueryRow("SELECT COUNT(*) FROM sessions;").Scan(&n); err != nil || n != 0 {
+		t.Errorf("sessions after expiring = %d, %v, want 0", n, err)
+	}
+}
+
+func TestSQLSessionStoreNoRoles(t *testing.T) {
+	db := testDB(t)
+
+	s := NewSQLSessionStore(db, time.Hour)
+
+	id, err := s.Create(&api.User{Username: "tech", DisplayName: "Tech Person", Roles: []api.Role{api.RoleCheckout}})
+	if err != nil {
+		t.Fatal("Create returned an error:", err)
+	}
+	if session, err := s.Check(id); err != nil || session == nil {
+		t.Fatalf("Check = %v, %v, want a session", session, err)
+	}
+
+	//a session serialized before roles were added
+	if _, err = db.Exec("INSERT INTO sessions(id, username, user, expires) VALUES (?, 'old', ?, ?);",
+		hashSessionID("old"), `{"Username":"old","DisplayName":"Old User"}`, time.Now().Add(time.Hour),
+	); err != nil {
+		t.Fatal("Could not insert session:", err)
+	}
+
+	if session, err := s.Check("old"); err != nil || session != nil {
+		t.Fatalf("Check of a session without roles = %v, %v, want nil", session, err)
+	}
+
+	var n int
+	if err = db.QueryRow("SELECT COUNT(*) FROM sessions WHERE username = 'old';").Scan(&n); err != nil {
+		t.Fatal("Could not count sessions:", err)
+	}
+	if n != 0 {
+		t.Error("Session without roles wasn't removed")
+	}
+}
+
+func TestSessionStoreDeleteUser(t *testing.T) {
+	for name, s := range map[string]SessionStore{
+		"memory": NewMemorySessionStore(time.Hour),
+		"sql":    NewSQLSessionStore(testDB(t), time.Hour),
+	} {
+		var ids []string
+		for _, username := range []string{"JDoe", "jdoe", "other"} {
+			id, err := s.Create(&api.User{Username: username, DisplayName: username, Roles: []api.Role{api.RoleViewer}})
+			if err != nil {
+				t.Fatalf("%s: Create returned an error: %v", name, err)
+			}
+			ids = append(ids, id)
+		}
+
+		if err := s.DeleteUser("jDOE"); err != nil {
+			t.Fatalf("%s: DeleteUser returned an error: %v", name, err)
+		}
+
+		for i, want := range []bool{false, false, true} {
+			session, err := s.Check(ids[i])
+			if err != nil {
+				t.Fatalf("%s: Check returned an error: %v", name, err)
+			}
+			if (session != nil) != want {
+				t.Errorf("%s: session %d after DeleteUser = %v, want exists = %v", name, i, session, want)
+			}
+		}
+
+		if err := s.Delete(ids[2]); err != nil {
+			t.Fatalf("%s: Delete returned an error: %v", name, err)
+		}
+		if session, err := s.Check(ids[2]); err != nil || session != nil {
+			t.Errorf("%s: Check after Delete = %v, %v, want nil", name, session, err)
+		}
+	}
+}
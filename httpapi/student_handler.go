@@ -2,19 +2,39 @@ package httpapi
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/korylprince/bisd-device-checkout-server/api"
 )
 
+// setRosterHeader sets the X-Roster-Refreshed header to the last roster refresh time if the roster is cached
+func setRosterHeader(w http.ResponseWriter) {
+	if api.Roster != nil {
+		w.Header().Set("X-Roster-Refreshed", api.Roster.Refreshed().Format(time.RFC3339))
+	}
+}
+
+// feeForgiveness returns whether or not the student has fee forgiveness,
+// or nil if the user only has the viewer role. Requests without a user (API keys) can see it
+func feeForgiveness(r *http.Request, s *api.Student) *bool {
+	if user, ok := r.Context().Value(api.UserKey).(*api.User); ok && !user.HasRole(api.RoleCheckout) && !user.HasRole(api.RoleCashier) {
+		return nil
+	}
+	return &(s.EconomicallyDisadvantaged)
+}
+
 // GET /students
-func handleReadStudentList(_ http.ResponseWriter, r *http.Request) *handlerResponse {
+func handleReadStudentList(w http.ResponseWriter, r *http.Request) *handlerResponse {
+	setRosterHeader(w)
+
 	type student struct {
 		FirstName      string `json:"first_name"`
 		LastName       string `json:"last_name"`
 		OtherID        string `json:"other_id"`
 		Grade          int    `json:"grade"`
-		FeeForgiveness bool   `json:"fee_forgiveness"`
+		Campus         string `json:"campus"`
+		FeeForgiveness *bool  `json:"fee_forgiveness,omitempty"`
 	}
 
 	type response []*student
@@ -26,14 +46,90 @@ func handleReadStudentList(_ http.ResponseWriter, r *http.Request) *handlerRespo
 
 	var list response
 	for _, s := range students {
-		list = append(list, &student{FirstName: s.FirstName, LastName: s.LastName, OtherID: s.OtherID, Grade: s.Grade, FeeForgiveness: s.EconomicallyDisadvantaged})
+		list = append(list, &student{FirstName: s.FirstName, LastName: s.LastName, OtherID: s.OtherID, Grade: s.Grade, Campus: s.Campus, FeeForgiveness: feeForgiveness(r, s)})
 	}
 
 	return &handlerResponse{Code: http.StatusOK, Body: list}
 }
 
+// GET /students/:otherID
+func handleReadStudent(w http.ResponseWriter, r *http.Request) *handlerResponse {
+	setRosterHeader(w)
+
+	type charge struct {
+		ID          int           `json:"id"`
+		Description string        `json:"description"`
+		Items       []*api.Damage `json:"items"`
+		Charged     float32       `json:"charged"`
+		Paid        float32       `json:"paid"`
+		Balance     float32       `json:"balance"`
+	}
+
+	type student struct {
+		FirstName      string        `json:"first_name"`
+		LastName       string        `json:"last_name"`
+		OtherID        string        `json:"other_id"`
+		Grade          int           `json:"grade"`
+		Campus         string        `json:"campus"`
+		FeeForgiveness *bool         `json:"fee_forgiveness,omitempty"`
+		Devices        []*api.Device `json:"devices"`
+		Charges        []*charge     `json:"charges"`
+	}
+
+	otherID := mux.Vars(r)["otherID"]
+
+	stu, err := api.GetStudent(r.Context(), otherID)
+	if resp := checkAPIError(err); resp != nil {
+		return resp
+	}
+
+	devices, err := stu.GetDevices(r.Context())
+	if resp := checkAPIError(err); resp != nil {
+		return resp
+	}
+
+	charges, err := stu.GetCharges(r.Context())
+	if resp := checkAPIError(err); resp != nil {
+		return resp
+	}
+
+	if devices == nil {
+		devices = make([]*api.Device, 0)
+	}
+
+	resp := &student{
+		FirstName:      stu.FirstName,
+		LastName:       stu.LastName,
+		OtherID:        stu.OtherID,
+		Grade:          stu.Grade,
+		Campus:         stu.Campus,
+		FeeForgiveness: feeForgiveness(r, stu),
+		Devices:        devices,
+		Charges:        make([]*charge, 0, len(charges)),
+	}
+
+	for _, c := range charges {
+		items := c.Items()
+		if items == nil {
+			items = make([]*api.Damage, 0)
+		}
+		resp.Charges = append(resp.Charges, &charge{
+			ID:          c.ID,
+			Description: c.Description(),
+			Items:       items,
+			Charged:     c.AmountCharged(),
+			Paid:        c.AmountPaid,
+			Balance:     c.AmountCharged() - c.AmountPaid,
+		})
+	}
+
+	return &handlerResponse{Code: http.StatusOK, Body: resp}
+}
+
 // GET /students/:otherID/status
-func handleReadStudentStatus(_ http.ResponseWriter, r *http.Request) *handlerResponse {
+func handleReadStudentStatus(w http.ResponseWriter, r *http.Request) *handlerResponse {
+	setRosterHeader(w)
+
 	otherID := mux.Vars(r)["otherID"]
 
 	student, err := api.GetStudent(r.Context(), otherID)
@@ -49,14 +145,36 @@ func handleReadStudentStatus(_ http.ResponseWriter, r *http.Request) *handlerRes
 	return &handlerResponse{Code: http.StatusOK, Body: status}
 }
 
+// GET /students/:otherID/status/explain
+func handleExplainStudentStatus(w http.ResponseWriter, r *http.Request) *handlerResponse {
+	setRosterHeader(w)
+
+	otherID := mux.Vars(r)["otherID"]
+
+	student, err := api.GetStudent(r.Context(), otherID)
+	if resp := checkAPIError(err); resp != nil {
+		return resp
+	}
+
+	explanation, err := student.Explain(r.Context())
+	if resp := checkAPIError(err); resp != nil {
+		return resp
+	}
+
+	return &handlerResponse{Code: http.StatusOK, Body: explanation}
+}
+
 // GET /students?status=true
-func handleReadStudentStatuses(_ http.ResponseWriter, r *http.Request) *handlerResponse {
+func handleReadStudentStatuses(w http.ResponseWriter, r *http.Request) *handlerResponse {
+	setRosterHeader(w)
+
 	type student struct {
 		FirstName      string      `json:"first_name"`
 		LastName       string      `json:"last_name"`
 		OtherID        string      `json:"other_id"`
 		Grade          int         `json:"grade"`
-		FeeForgiveness bool        `json:"fee_forgiveness"`
+		Campus         string      `json:"campus"`
+		FeeForgiveness *bool       `json:"fee_forgiveness,omitempty"`
 		Status         *api.Status `json:"status"`
 	}
 
@@ -65,20 +183,22 @@ func handleReadStudentStatuses(_ http.ResponseWriter, r *http.Request) *handlerR
 		return resp
 	}
 
+	statuses, err := api.GetStatuses(r.Context(), students)
+	if resp := checkAPIError(err); resp != nil {
+		return resp
+	}
+
 	var list []*student
 
 	for _, stu := range students {
-		status, err := stu.Status(r.Context())
-		if resp := checkAPIError(err); resp != nil {
-			return resp
-		}
 		list = append(list, &student{
 			FirstName:      stu.FirstName,
 			LastName:       stu.LastName,
 			OtherID:        stu.OtherID,
 			Grade:          stu.Grade,
-			FeeForgiveness: stu.EconomicallyDisadvantaged,
-			Status:         status,
+			Campus:         stu.Campus,
+			FeeForgiveness: feeForgiveness(r, stu),
+			Status:         statuses[stu.OtherID],
 		})
 	}
 
@@ -0,0 +1,35 @@
+package httpapi
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/korylprince/bisd-device-checkout-server/api"
+)
+
+func TestFeeForgiveness(t *testing.T) {
+	s := &api.Student{EconomicallyDisadvantaged: true}
+
+	for _, test := range []struct {
+		name    string
+		user    *api.User
+		visible bool
+	}{
+		{"api key", nil, true},
+		{"viewer", &api.User{Roles: []api.Role{api.RoleViewer}}, false},
+		{"checkout", &api.User{Roles: []api.Role{api.RoleCheckout}}, true},
+		{"cashier", &api.User{Roles: []api.Role{api.RoleCashier}}, true},
+		{"admin", &api.User{Roles: []api.Role{api.RoleAdmin}}, true},
+	} {
+		r := httptest.NewRequest("GET", "/students/100001", nil)
+		if test.user != nil {
+			r = r.WithContext(context.WithValue(r.Context(), api.UserKey, test.user))
+		}
+
+		f := feeForgiveness(r, s)
+		if (f != nil) != test.visible || (f != nil && !*f) {
+			t.Errorf("%s: feeForgiveness = %v, want visible = %v", test.name, f, test.visible)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package httpapi
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// login throttling settings. After the allowed number of failed logins, each failure doubles the time
+// before another login is allowed, starting at loginBackoff, until the username or IP address is locked out for loginLockout
+const (
+	//loginUserFailures is the number of failed logins allowed for a username before backing off
+	loginUserFailures = 5
+	//loginIPFailures is the number of failed logins allowed from an IP address before backing off.
+	//It's higher than loginUserFailures since many users may share an address.
+	//If the server is behind a reverse proxy, TrustedProxies must be set or every client will share the proxy's address
+	loginIPFailures = 20
+	loginBackoff    = time.Second
+	loginLockout    = 15 * time.Minute
+	//loginReset is how long after the last failed login the failures are forgotten
+	loginReset = time.Hour
+	//loginMaxKeys is the most usernames or IP addresses tracked at once. Once reached, logins for new keys are throttled until older records expire
+	loginMaxKeys = 10000
+)
+
+// loginFailures represents the failed logins for a username or IP address
+type loginFailures struct {
+	count int
+	//pending is the number of logins that have begun but not ended
+	pending int
+	last    time.Time
+	until   time.Time
+}
+
+// loginThrottle tracks failed logins by key, delaying further logins with exponential backoff
+type loginThrottle struct {
+	failures map[string]*loginFailures
+	allowed  int
+	mu       *sync.Mutex
+}
+
+// scavenge removes stale records. t.mu must be held
+func (t *loginThrottle) scavenge(now time.Time) {
+	for key, f := range t.failures {
+		if f.pending == 0 && now.Sub(f.last) > loginReset && now.After(f.until) {
+			delete(t.failures, key)
+		}
+	}
+}
+
+// scavengeLogins removes stale records every hour
+func scavengeLogins(t *loginThrottle) {
+	for {
+		time.Sleep(time.Hour)
+		t.mu.Lock()
+		t.scavenge(time.Now())
+		t.mu.Unlock()
+	}
+}
+
+// newLoginThrottle returns a new loginThrottle that allows the given number of failed logins before backing off
+func newLoginThrottle(allowed int) *loginThrottle {
+	t := &loginThrottle{
+		failures: make(map[string]*loginFailures),
+		allowed:  allowed,
+		mu:       new(sync.Mutex),
+	}
+	go scavengeLogins(t)
+	return t
+}
+
+// get returns the loginFailures for key, forgetting failures older than loginReset. t.mu must be held
+func (t *loginThrottle) get(key string, now time.Time) *loginFailures {
+	f, ok := t.failures[key]
+	if !ok {
+		f = &loginFailures{last: now}
+		t.failures[key] = f
+	}
+	if f.pending == 0 && now.Sub(f.last) > loginReset {
+		f.count = 0
+	}
+	return f
+}
+
+// begin reserves a login for key, returning 0 if the login is allowed, or how long key must wait before logging in again.
+// Pending logins count as failures, so concurrent logins can't exceed the allowed failures.
+// If the login is allowed, end must be called when it's finished
+func (t *loginThrottle) begin(key string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+
+	if _, ok := t.failures[key]; !ok && len(t.failures) >= loginMaxKeys {
+		if t.scavenge(now); len(t.failures) >= loginMaxKeys {
+			return loginBackoff
+		}
+	}
+
+	f := t.get(key, now)
+
+	if d := f.until.Sub(now); d > 0 {
+		return d
+	}
+
+	//once backing off, only one login at a time is allowed
+	allowed := t.allowed - f.count
+	if allowed < 1 {
+		allowed = 1
+	}
+	if f.pending >= allowed {
+		return loginBackoff
+	}
+
+	f.pending++
+	return 0
+}
+
+// end finishes a login for key started with begin. If failed is true, the failure is recorded and
+// how long key must wait before logging in again is returned
+func (t *loginThrottle) end(key string, failed bool) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	f := t.get(key, now)
+
+	if f.pending > 0 {
+		f.pending--
+	}
+
+	if !failed {
+		//keys without failures aren't kept, so logins that don't fail don't fill the map
+		if f.count == 0 && f.pending == 0 {
+			delete(t.failures, key)
+		}
+		return 0
+	}
+
+	f.count++
+	f.last = now
+
+	if f.count <= t.allowed {
+		return 0
+	}
+
+	d := loginLockout
+	//check the shift so it can't overflow
+	if n := f.count - t.allowed - 1; n < 32 && loginBackoff<<uint(n) < loginLockout {
+		d = loginBackoff << uint(n)
+	}
+
+	f.until = now.Add(d)
+	return d
+}
+
+// reset forgets the failed logins for key
+func (t *loginThrottle) reset(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if f, ok := t.failures[key]; ok {
+		if f.pending == 0 {
+			delete(t.failures, key)
+			return
+		}
+		f.count = 0
+		f.until = time.Time{}
+	}
+}
+
+// beginLogin reserves a login for username from ip, returning 0 if the login is allowed, or how long the client must wait.
+// The address is checked first, so a throttled address can't add usernames to users.
+// If the login is allowed, it must be ended in both users and ips
+func beginLogin(users, ips *loginThrottle, username, ip string) time.Duration {
+	if wait := ips.begin(ip); wait > 0 {
+		return wait
+	}
+	if wait := users.begin(username); wait > 0 {
+		ips.end(ip, false)
+		return wait
+	}
+	return 0
+}
+
+// throttled returns a 429 handlerResponse telling the client to wait before logging in again
+func throttled(w http.ResponseWriter, wait time.Duration) *handlerResponse {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	return handleError(http.StatusTooManyRequests, fmt.Errorf("Too many failed logins; try again in %v", wait.Round(time.Second)))
+}
+
+// TrustedProxies are the addresses of reverse proxies whose X-Forwarded-For headers are used to find the client's IP address
+var TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses a list of IP addresses and CIDR ranges
+func ParseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, p := range proxies {
+		p = strings.TrimSpace(p)
+		if !strings.Contains(p, "/") {
+			ip := net.ParseIP(p)
+			if ip == nil {
+				return nil, fmt.Errorf("Invalid IP address: %s", p)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid CIDR range: %s", p)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// trustedProxy returns whether or not addr is in TrustedProxies
+func trustedProxy(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range TrustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteIP returns the IP address of the client that made r. If r was made by a trusted proxy,
+// the last address in the X-Forwarded-For header that wasn't added by a trusted proxy is returned
+func remoteIP(r *http.Request) string {
+	addr, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		addr = r.RemoteAddr
+	}
+
+	if !trustedProxy(addr) {
+		return addr
+	}
+
+	//each proxy appends the address it received the request from
+	var forwarded []string
+	for _, h := range r.Header.Values("X-Forwarded-For") {
+		forwarded = append(forwarded, strings.Split(h, ",")...)
+	}
+
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		f := strings.TrimSpace(forwarded[i])
+		if f == "" {
+			continue
+		}
+		addr = f
+		if !trustedProxy(f) {
+			break
+		}
+	}
+
+	return addr
+}
@@ -0,0 +1,210 @@
+package httpapi
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLoginThrottleBackoff(t *testing.T) {
+	th := newLoginThrottle(3)
+
+	for i := 1; i <= 3; i++ {
+		if wait := th.begin("user"); wait != 0 {
+			t.Fatalf("begin after %d failures = %v, want 0", i-1, wait)
+		}
+		if wait := th.end("user", true); wait != 0 {
+			t.Fatalf("end after %d failures = %v, want 0", i, wait)
+		}
+	}
+
+	if wait := th.begin("user"); wait != 0 {
+		t.Fatalf("begin after allowed failures = %v, want 0", wait)
+	}
+	if wait := th.end("user", true); wait != loginBackoff {
+		t.Fatalf("end after first backoff failure = %v, want %v", wait, loginBackoff)
+	}
+	if wait := th.begin("user"); wait <= 0 || wait > loginBackoff {
+		t.Fatalf("begin while backing off = %v, want (0, %v]", wait, loginBackoff)
+	}
+
+	//each failure doubles the backoff up to the lockout
+	th.failures["user"].until = time.Time{}
+	want := 2 * loginBackoff
+	for want < loginLockout {
+		th.begin("user")
+		if wait := th.end("user", true); wait != want {
+			t.Fatalf("end = %v, want %v", wait, want)
+		}
+		th.failures["user"].until = time.Time{}
+		want *= 2
+	}
+	th.begin("user")
+	if wait := th.end("user", true); wait != loginLockout {
+		t.Fatalf("end = %v, want lockout %v", wait, loginLockout)
+	}
+
+	th.reset("user")
+	if wait := th.begin("user"); wait != 0 {
+		t.Fatalf("begin after reset = %v, want 0", wait)
+	}
+}
+
+func TestLoginThrottleConcurrent(t *testing.T) {
+	th := newLoginThrottle(3)
+
+	//pending logins count against the allowed failures before any of them fail
+	for i := 0; i < 3; i++ {
+		if wait := th.begin("user"); wait != 0 {
+			t.Fatalf("begin %d = %v, want 0", i, wait)
+		}
+	}
+	if wait := th.begin("user"); wait == 0 {
+		t.Fatal("begin with allowed logins pending = 0, want backoff")
+	}
+
+	//a login that ends without failing frees its reservation
+	th.end("user", false)
+	if wait := th.begin("user"); wait != 0 {
+		t.Fatalf("begin after a login ended = %v, want 0", wait)
+	}
+
+	for i := 0; i < 3; i++ {
+		th.end("user", true)
+	}
+
+	//once backing off, only one login is allowed at a time
+	th.failures["user"].until = time.Time{}
+	if wait := th.begin("user"); wait != 0 {
+		t.Fatalf("begin after backoff expired = %v, want 0", wait)
+	}
+	if wait := th.begin("user"); wait == 0 {
+		t.Fatal("second concurrent begin while backing off = 0, want backoff")
+	}
+}
+
+func TestLoginThrottleKeys(t *testing.T) {
+	th := newLoginThrottle(1)
+
+	th.begin("a")
+	th.end("a", true)
+	th.begin("a")
+	th.end("a", true)
+
+	if wait := th.begin("a"); wait == 0 {
+		t.Fatal("begin for throttled key = 0, want backoff")
+	}
+	if wait := th.begin("b"); wait != 0 {
+		t.Fatalf("begin for other key = %v, want 0", wait)
+	}
+}
+
+func TestBeginLoginIPFirst(t *testing.T) {
+	users, ips := newLoginThrottle(loginUserFailures), newLoginThrottle(1)
+
+	if wait := beginLogin(users, ips, "user0", "10.0.0.1"); wait != 0 {
+		t.Fatalf("beginLogin = %v, want 0", wait)
+	}
+	users.end("user0", true)
+	ips.end("10.0.0.1", true)
+	if wait := beginLogin(users, ips, "user1", "10.0.0.1"); wait != 0 {
+		t.Fatalf("beginLogin = %v, want 0", wait)
+	}
+	users.end("user1", true)
+	ips.end("10.0.0.1", true)
+
+	//the address is throttled, so spraying usernames from it doesn't record them
+	for i := 2; i < 100; i++ {
+		if wait := beginLogin(users, ips, "user"+strconv.Itoa(i), "10.0.0.1"); wait == 0 {
+			t.Fatalf("beginLogin from a throttled address = 0, want backoff")
+		}
+	}
+	if len(users.failures) != 2 {
+		t.Errorf("users has %d keys, want 2", len(users.failures))
+	}
+
+	//a throttled username releases the address's reservation
+	users.failures["user0"].until = time.Now().Add(time.Minute)
+	if wait := beginLogin(users, ips, "user0", "10.0.0.2"); wait == 0 {
+		t.Fatal("beginLogin of a throttled username = 0, want backoff")
+	}
+	if f := ips.failures["10.0.0.2"]; f != nil && f.pending != 0 {
+		t.Errorf("address has %d pending logins, want 0", f.pending)
+	}
+}
+
+func TestLoginThrottleMaxKeys(t *testing.T) {
+	th := newLoginThrottle(loginUserFailures)
+
+	//logins that don't fail aren't kept
+	th.begin("ok")
+	th.end("ok", false)
+	if len(th.failures) != 0 {
+		t.Fatalf("throttle has %d keys after a successful login, want 0", len(th.failures))
+	}
+
+	for i := 0; i < loginMaxKeys; i++ {
+		key := "user" + strconv.Itoa(i)
+		th.begin(key)
+		th.end(key, true)
+	}
+
+	if wait := th.begin("new"); wait == 0 {
+		t.Fatal("begin of a new key with the throttle full = 0, want backoff")
+	}
+	if len(th.failures) != loginMaxKeys {
+		t.Errorf("throttle has %d keys, want %d", len(th.failures), loginMaxKeys)
+	}
+
+	//tracked keys can still log in
+	if wait := th.begin("user0"); wait != 0 {
+		t.Errorf("begin of a tracked key = %v, want 0", wait)
+	}
+	th.end("user0", false)
+
+	//stale records are removed to make room
+	th.failures["user1"].last = time.Now().Add(-2 * loginReset)
+	if wait := th.begin("new"); wait != 0 {
+		t.Errorf("begin of a new key after a record expired = %v, want 0", wait)
+	}
+}
+
+func TestRemoteIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.5", "10.1.0.0/16"})
+	if err != nil {
+		t.Fatal("ParseTrustedProxies returned an error:", err)
+	}
+	TrustedProxies = proxies
+	defer func() { TrustedProxies = nil }()
+
+	for _, test := range []struct {
+		remote    string
+		forwarded string
+		want      string
+	}{
+		{"192.168.1.10:1234", "", "192.168.1.10"},
+		//untrusted clients can't set their address
+		{"192.168.1.10:1234", "1.2.3.4", "192.168.1.10"},
+		{"10.0.0.5:1234", "", "10.0.0.5"},
+		{"10.0.0.5:1234", "192.168.1.10", "192.168.1.10"},
+		//addresses added by the client are ignored
+		{"10.0.0.5:1234", "1.2.3.4, 192.168.1.10", "192.168.1.10"},
+		//addresses added by trusted proxies are skipped
+		{"10.0.0.5:1234", "192.168.1.10, 10.1.2.3", "192.168.1.10"},
+		{"10.0.0.5:1234", "10.1.2.3", "10.1.2.3"},
+	} {
+		r := httptest.NewRequest("POST", "/auth", nil)
+		r.RemoteAddr = test.remote
+		if test.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", test.forwarded)
+		}
+		if ip := remoteIP(r); ip != test.want {
+			t.Errorf("remoteIP(%s, X-Forwarded-For: %s) = %s, want %s", test.remote, test.forwarded, ip, test.want)
+		}
+	}
+
+	if _, err := ParseTrustedProxies([]string{"bad"}); err == nil {
+		t.Error("ParseTrustedProxies(bad) didn't return an error")
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
+	"github.com/gorilla/mux"
 	"github.com/korylprince/bisd-device-checkout-server/api"
 )
 
@@ -21,6 +23,9 @@ func handleAuthenticate(config *api.AuthConfig, s SessionStore) returnHandler {
 		User      *api.User `json:"user"`
 	}
 
+	users := newLoginThrottle(loginUserFailures)
+	ips := newLoginThrottle(loginIPFailures)
+
 	return func(w http.ResponseWriter, r *http.Request) *handlerResponse {
 		var req *request
 		d := json.NewDecoder(r.Body)
@@ -34,14 +39,35 @@ func handleAuthenticate(config *api.AuthConfig, s SessionStore) returnHandler {
 			return handleError(http.StatusBadRequest, errors.New("username or password empty"))
 		}
 
+		username, ip := strings.ToLower(req.Username), remoteIP(r)
+
+		if wait := beginLogin(users, ips, username, ip); wait > 0 {
+			return throttled(w, wait)
+		}
+
 		user, err := api.Authenticate(config, req.Username, req.Password)
+		//errors aren't counted as failures, since they aren't caused by a bad password
+		failed := err == nil && user == nil
+		userWait, ipWait := users.end(username, failed), ips.end(ip, failed)
+
 		if err != nil {
 			return handleError(http.StatusUnauthorized, fmt.Errorf("Could not authenticate user %s: %v", req.Username, err))
 		}
 		if user == nil {
-			return handleError(http.StatusUnauthorized, errors.New("Bad username or password"))
+			resp := handleError(http.StatusUnauthorized, errors.New("Bad username or password"))
+			//lockouts are only logged, not returned to the client
+			if userWait >= loginLockout {
+				resp.Err = fmt.Errorf("%v; user %s locked out for %v", resp.Err, req.Username, loginLockout)
+			}
+			if ipWait >= loginLockout {
+				resp.Err = fmt.Errorf("%v; address %s locked out for %v", resp.Err, ip, loginLockout)
+			}
+			return resp
 		}
 
+		//only the username is reset, so a valid login doesn't reset failures from a shared address
+		users.reset(username)
+
 		id, err := s.Create(user)
 		if err != nil {
 			return handleError(http.StatusInternalServerError, fmt.Errorf("Could not create session: %v", err))
@@ -50,3 +76,32 @@ func handleAuthenticate(config *api.AuthConfig, s SessionStore) returnHandler {
 		return &handlerResponse{Code: http.StatusOK, Body: &response{SessionID: id, User: user}}
 	}
 }
+
+// DELETE /auth
+func handleLogout(s SessionStore) returnHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlerResponse {
+		id, resp := sessionID(r)
+		if resp != nil {
+			return resp
+		}
+
+		if err := s.Delete(id); err != nil {
+			return handleError(http.StatusInternalServerError, fmt.Errorf("Could not delete session: %v", err))
+		}
+
+		return &handlerResponse{Code: http.StatusOK, Body: map[string]string{"Status": "OK"}}
+	}
+}
+
+// DELETE /sessions/:username
+func handleDeleteUserSessions(s SessionStore) returnHandler {
+	return func(w http.ResponseWriter, r *http.Request) *handlerResponse {
+		username := mux.Vars(r)["username"]
+
+		if err := s.DeleteUser(username); err != nil {
+			return handleError(http.StatusInternalServerError, fmt.Errorf("Could not delete sessions for %s: %v", username, err))
+		}
+
+		return &handlerResponse{Code: http.StatusOK, Body: map[string]string{"Status": "OK"}}
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/korylprince/bisd-device-checkout-server/api"
 	"github.com/korylprince/bisd-device-checkout-server/httpapi"
 	auth "github.com/korylprince/go-ad-auth/v3"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 func main() {
@@ -21,9 +22,51 @@ func main() {
 		log.Fatalln("Could not open Inventory database:", err)
 	}
 
-	skywardDB, err := sql.Open("odbc", config.SkywardDSN)
-	if err != nil {
-		log.Fatalln("Could not open Skyward database:", err)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(inventoryDB, os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "apikey" {
+		runAPIKey(inventoryDB, os.Args[2:])
+		return
+	}
+
+	checkServerConfig()
+
+	switch config.SQLDriver {
+	case "mysql":
+		api.Devices = api.NewMySQLRepository()
+		api.Charges = api.NewMySQLRepository()
+		api.Loans = api.NewMySQLRepository()
+		api.Checkouts = api.NewMySQLRepository()
+		api.Payments = api.NewMySQLRepository()
+	case "sqlite3":
+		api.Devices = api.NewSQLiteRepository()
+		api.Charges = api.NewSQLiteRepository()
+		api.Loans = api.NewSQLiteRepository()
+		api.Checkouts = api.NewSQLiteRepository()
+		api.Payments = api.NewSQLiteRepository()
+	}
+
+	var skywardDB *sql.DB
+	switch config.StudentSource {
+	case "skyward":
+		skywardDB, err = sql.Open("odbc", config.SkywardDSN)
+		if err != nil {
+			log.Fatalln("Could not open Skyward database:", err)
+		}
+		api.Students = &api.SkywardSource{DB: skywardDB}
+	case "oneroster":
+		api.Students = &api.OneRosterSource{Dir: config.OneRosterDir}
+	}
+
+	if config.RosterRefresh > 0 {
+		roster, err := api.NewRosterCache(api.Students, time.Minute*time.Duration(config.RosterRefresh))
+		if err != nil {
+			log.Fatalln("Could not load roster:", err)
+		}
+		api.Roster = roster
 	}
 
 	adConfig := &api.AuthConfig{
@@ -33,17 +76,40 @@ func main() {
 			BaseDN:   config.LDAPBaseDN,
 			Security: config.ldapSecurity,
 		},
-		Group: config.LDAPGroup,
+		Group:      config.LDAPGroup,
+		AdminGroup: config.LDAPAdminGroup,
+		RoleGroups: config.ldapRoleGroups,
+	}
+
+	if config.RulesFile != "" {
+		rules, err := api.LoadRuleSet(config.RulesFile)
+		if err != nil {
+			log.Fatalln("Could not load rules:", err)
+		}
+		api.Rules = rules
 	}
 
-	s := httpapi.NewMemorySessionStore(time.Minute * time.Duration(config.SessionExpiration))
+	api.UndoWindow = time.Minute * time.Duration(config.UndoWindow)
+
+	var s httpapi.SessionStore
+	switch config.SessionStore {
+	case "memory":
+		s = httpapi.NewMemorySessionStore(time.Minute * time.Duration(config.SessionExpiration))
+	case "sql":
+		s = httpapi.NewSQLSessionStore(inventoryDB, time.Minute*time.Duration(config.SessionExpiration))
+	}
+
+	httpapi.TrustedProxies = config.trustedProxies
+
+	keys := httpapi.NewAPIKeyStore(inventoryDB, config.APIKey)
 
-	r := httpapi.NewRouter(os.Stdout, adConfig, config.APIKey, s, inventoryDB, skywardDB)
+	r := httpapi.NewRouter(os.Stdout, adConfig, keys, s, inventoryDB, skywardDB)
 
 	chain := handlers.CompressHandler(handlers.CORS(
 		handlers.AllowedOrigins([]string{"*"}),
-		handlers.AllowedMethods([]string{"GET", "POST", "OPTIONS"}),
+		handlers.AllowedMethods([]string{"GET", "POST", "DELETE", "OPTIONS"}),
 		handlers.AllowedHeaders([]string{"Accept", "Content-Type", "Origin", "X-Session-Key"}),
+		handlers.ExposedHeaders([]string{"X-Roster-Refreshed", "X-Request-ID", "Retry-After"}),
 	)(http.StripPrefix(config.Prefix, r)))
 
 	log.Println("Listening on:", config.ListenAddr)
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/korylprince/bisd-device-checkout-server/migrations"
+)
+
+const migrateUsage = `Usage: %s migrate <command>
+
+Commands:
+  up [n]    apply the next n migrations (default: all)
+  down [n]  roll back the last n migrations (default: 1)
+  status    show applied and pending migrations
+`
+
+// runMigrate runs the migrate subcommand with the given args
+func runMigrate(db *sql.DB, args []string) {
+	if len(args) == 0 || len(args) > 2 {
+		fmt.Fprintf(os.Stderr, migrateUsage, os.Args[0])
+		os.Exit(2)
+	}
+
+	var n int
+	if len(args) == 2 {
+		var err error
+		if n, err = strconv.Atoi(args[1]); err != nil || n < 1 {
+			log.Fatalln("Invalid number of migrations:", args[1])
+		}
+	}
+
+	switch args[0] {
+	case "up":
+		done, err := migrations.Up(db, config.SQLDriver, n)
+		for _, m := range done {
+			log.Printf("Applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalln("Could not apply migrations:", err)
+		}
+		if len(done) == 0 {
+			log.Println("No migrations to apply")
+		}
+	case "down":
+		if n == 0 {
+			n = 1
+		}
+		done, err := migrations.Down(db, config.SQLDriver, n)
+		for _, m := range done {
+			log.Printf("Rolled back %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalln("Could not roll back migrations:", err)
+		}
+		if len(done) == 0 {
+			log.Println("No migrations to roll back")
+		}
+	case "status":
+		if len(args) != 1 {
+			fmt.Fprintf(os.Stderr, migrateUsage, os.Args[0])
+			os.Exit(2)
+		}
+		statuses, err := migrations.Status(db, config.SQLDriver)
+		if err != nil {
+			log.Fatalln("Could not read migration status:", err)
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.Applied != nil {
+				applied = s.Applied.Local().Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-24s %s\n", s.Version, s.Name, applied)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, migrateUsage, os.Args[0])
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,279 @@
+// Package migrations contains the versioned schema for the inventory database
+// and functions to apply and roll back the schema.
+//
+// Each migration runs in a transaction, but MySQL commits every DDL statement immediately,
+// so a migration that fails partway through can be left partly applied without being recorded.
+// Migrations are written so they can be run again after fixing the cause: tables and indexes are
+// created with IF NOT EXISTS and dropped with IF EXISTS, and migrations that can't be written that way
+// (like ALTER TABLE) have a single statement
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed mysql/*.sql sqlite3/*.sql
+var files embed.FS
+
+// Migration represents a single versioned schema change
+type Migration struct {
+	Version int
+	Name    string
+	up      string
+	down    string
+}
+
+// MigrationStatus represents a Migration and whether or not it has been applied
+type MigrationStatus struct {
+	*Migration
+	Applied *time.Time
+}
+
+// Load returns the migrations for the given SQL driver, sorted by version
+func Load(driver string) ([]*Migration, error) {
+	entries, err := fs.ReadDir(files, driver)
+	if err != nil {
+		return nil, fmt.Errorf("No migrations for driver %s", driver)
+	}
+
+	index := make(map[int]*Migration)
+	for _, e := range entries {
+		//files are named <version>_<name>.<up|down>.sql
+		name := strings.TrimSuffix(e.Name(), ".sql")
+		direction := path.Ext(name)
+		name = strings.TrimSuffix(name, direction)
+
+		parts := strings.SplitN(name, "_", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Invalid migration filename: %s", e.Name())
+		}
+
+		version, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid migration version: %s", e.Name())
+		}
+
+		buf, err := files.ReadFile(path.Join(driver, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("Could not read migration %s: %w", e.Name(), err)
+		}
+
+		m, ok := index[version]
+		if !ok {
+			m = &Migration{Version: version, Name: parts[1]}
+			index[version] = m
+		}
+
+		switch direction {
+		case ".up":
+			m.up = string(buf)
+		case ".down":
+			m.down = string(buf)
+		default:
+			return nil, fmt.Errorf("Invalid migration direction: %s", e.Name())
+		}
+	}
+
+	var migrations []*Migration
+	for _, m := range index {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("Migration %04d_%s must have up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// statements splits a migration into individual statements,
+// since not all drivers can execute multiple statements at once.
+// Lines beginning with "--" are comments, so a migration with only comments does nothing
+func statements(migration string) []string {
+	var lines []string
+	for _, line := range strings.Split(migration, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			lines = append(lines, line)
+		}
+	}
+
+	var stmts []string
+	for _, stmt := range strings.Split(strings.Join(lines, "\n"), ";") {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
+
+// createTable creates the schema_migrations table if it doesn't exist
+func createTable(db *sql.DB) error {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+	  version INTEGER PRIMARY KEY,
+	  name varchar(255) NOT NULL,
+	  applied datetime NOT NULL
+	);`)
+	if err != nil {
+		return fmt.Errorf("Could not create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+// applied returns the applied time of every applied migration, indexed by version
+func applied(db *sql.DB) (map[int]time.Time, error) {
+	if err := createTable(db); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query("SELECT version, applied FROM schema_migrations;")
+	if err != nil {
+		return nil, fmt.Errorf("Could not query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	versions := make(map[int]time.Time)
+	for rows.Next() {
+		var (
+			version int
+			date    time.Time
+		)
+		if err = rows.Scan(&version, &date); err != nil {
+			return nil, fmt.Errorf("Could not scan schema_migrations row: %w", err)
+		}
+		versions[version] = date
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("Could not scan schema_migrations rows: %w", err)
+	}
+
+	return versions, nil
+}
+
+// run executes the statements in migration and records or removes the given version in schema_migrations.
+// On MySQL, DDL statements are committed even if the transaction is rolled back
+func run(db *sql.DB, m *Migration, up bool) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("Could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	migration := m.down
+	if up {
+		migration = m.up
+	}
+
+	for _, stmt := range statements(migration) {
+		if _, err = tx.Exec(stmt); err != nil {
+			return fmt.Errorf("Could not run migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+	}
+
+	if up {
+		_, err = tx.Exec("INSERT INTO schema_migrations(version, name, applied) VALUES (?, ?, ?);", m.Version, m.Name, time.Now())
+	} else {
+		_, err = tx.Exec("DELETE FROM schema_migrations WHERE version = ?;", m.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("Could not update schema_migrations for %04d_%s: %w", m.Version, m.Name, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("Could not commit migration %04d_%s: %w", m.Version, m.Name, err)
+	}
+
+	return nil
+}
+
+// Up applies up to n unapplied migrations in order, or all of them if n is 0,
+// and returns the migrations that were applied
+func Up(db *sql.DB, driver string, n int) ([]*Migration, error) {
+	migrations, err := Load(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []*Migration
+	for _, m := range migrations {
+		if n > 0 && len(done) == n {
+			break
+		}
+		if _, ok := versions[m.Version]; ok {
+			continue
+		}
+		if err = run(db, m, true); err != nil {
+			return done, err
+		}
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+// Down rolls back up to n applied migrations in reverse order
+// and returns the migrations that were rolled back
+func Down(db *sql.DB, driver string, n int) ([]*Migration, error) {
+	migrations, err := Load(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []*Migration
+	for i := len(migrations) - 1; i >= 0 && len(done) < n; i-- {
+		m := migrations[i]
+		if _, ok := versions[m.Version]; !ok {
+			continue
+		}
+		if err = run(db, m, false); err != nil {
+			return done, err
+		}
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+// Status returns the status of every migration for the given driver
+func Status(db *sql.DB, driver string) ([]*MigrationStatus, error) {
+	migrations, err := Load(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []*MigrationStatus
+	for _, m := range migrations {
+		s := &MigrationStatus{Migration: m}
+		if date, ok := versions[m.Version]; ok {
+			s.Applied = &date
+		}
+		statuses = append(statuses, s)
+	}
+
+	return statuses, nil
+}
@@ -0,0 +1,84 @@
+package migrations
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestUpDown(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal("Could not open database:", err)
+	}
+	//each connection to :memory: is a separate database
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	migrations, err := Load("sqlite3")
+	if err != nil {
+		t.Fatal("Load returned an error:", err)
+	}
+
+	done, err := Up(db, "sqlite3", 0)
+	if err != nil {
+		t.Fatal("Up returned an error:", err)
+	}
+	if len(done) != len(migrations) {
+		t.Fatalf("Up applied %d migrations, want %d", len(done), len(migrations))
+	}
+
+	if done, err = Up(db, "sqlite3", 0); err != nil || len(done) != 0 {
+		t.Fatalf("second Up applied %d migrations with error %v, want none", len(done), err)
+	}
+
+	if _, err = db.Exec("INSERT INTO devices(Bag_Tag, Status) VALUES ('1001', 'Storage');"); err != nil {
+		t.Fatal("Could not insert device:", err)
+	}
+
+	done, err = Down(db, "sqlite3", len(migrations))
+	if err != nil {
+		t.Fatal("Down returned an error:", err)
+	}
+	if len(done) != len(migrations) {
+		t.Fatalf("Down rolled back %d migrations, want %d", len(done), len(migrations))
+	}
+
+	//adopted tables are kept
+	var n int
+	if err = db.QueryRow("SELECT COUNT(*) FROM devices;").Scan(&n); err != nil || n != 1 {
+		t.Fatalf("devices has %d rows with error %v after Down, want 1", n, err)
+	}
+
+	//tables created by migrations are dropped
+	if _, err = db.Exec("SELECT COUNT(*) FROM payments;"); err == nil {
+		t.Error("payments table exists after Down")
+	}
+
+	statuses, err := Status(db, "sqlite3")
+	if err != nil {
+		t.Fatal("Status returned an error:", err)
+	}
+	for _, s := range statuses {
+		if s.Applied != nil {
+			t.Errorf("Migration %04d_%s is applied after Down", s.Version, s.Name)
+		}
+	}
+
+	//the adopted tables are adopted again
+	if done, err = Up(db, "sqlite3", 0); err != nil || len(done) != len(migrations) {
+		t.Fatalf("Up after Down applied %d migrations with error %v, want %d", len(done), err, len(migrations))
+	}
+}
+
+func TestStatements(t *testing.T) {
+	stmts := statements("-- a comment; with a semicolon\nCREATE TABLE a (id int);\n\n-- another\nDROP TABLE b;\n")
+	if len(stmts) != 2 || stmts[0] != "CREATE TABLE a (id int)" || stmts[1] != "DROP TABLE b" {
+		t.Errorf("statements = %q", stmts)
+	}
+
+	if stmts = statements("-- only a comment\n"); len(stmts) != 0 {
+		t.Errorf("statements of a comment = %q, want none", stmts)
+	}
+}
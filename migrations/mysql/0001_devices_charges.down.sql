@@ -0,0 +1,2 @@
+-- devices and charges may have existed before migrations and been adopted by the up migration,
+-- so rolling back keeps them and their data
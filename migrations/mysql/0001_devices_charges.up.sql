@@ -1,4 +1,4 @@
-CREATE TABLE devices (
+CREATE TABLE IF NOT EXISTS devices (
   id INTEGER UNSIGNED PRIMARY KEY AUTO_INCREMENT,
   Inventory_Number varchar(255) DEFAULT NULL,
   Serial_Number varchar(255) DEFAULT NULL,
@@ -14,14 +14,14 @@ CREATE TABLE devices (
   on_network char(1) DEFAULT NULL,
   notes longtext,
   po_number varchar(255) DEFAULT NULL,
-  UNIQUE KEY Inventory Number (Inventory_Number)
-)
+  UNIQUE KEY `Inventory Number` (Inventory_Number)
+);
 
-CREATE TABLE charges (
+CREATE TABLE IF NOT EXISTS charges (
   id INTEGER UNSIGNED PRIMARY KEY AUTO_INCREMENT,
   Inventory_Number varchar(255) DEFAULT NULL,
   User varchar(255) DEFAULT NULL,
   Amount_Paid double DEFAULT NULL,
   Charges longtext,
   Notes longtext
-)
+);
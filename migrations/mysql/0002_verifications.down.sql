@@ -0,0 +1,2 @@
+-- verifications may have existed before migrations and been adopted by the up migration,
+-- so rolling back keeps it and its data
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS verifications (
+  id INTEGER UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+  device_id INTEGER UNSIGNED NOT NULL,
+  username varchar(255) NOT NULL,
+  date datetime NOT NULL,
+  KEY device_id (device_id)
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS loans (
+  id INTEGER UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+  device_id INTEGER UNSIGNED NOT NULL,
+  other_id varchar(255) NOT NULL,
+  user varchar(255) NOT NULL,
+  username varchar(255) NOT NULL,
+  checked_out datetime NOT NULL,
+  due datetime NOT NULL,
+  returned datetime DEFAULT NULL,
+  KEY device_id (device_id),
+  KEY other_id (other_id)
+);
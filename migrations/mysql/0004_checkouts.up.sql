@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS checkouts (
+  id INTEGER UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+  device_id INTEGER UNSIGNED NOT NULL,
+  verification_id INTEGER UNSIGNED NOT NULL,
+  other_id varchar(255) NOT NULL,
+  user varchar(255) NOT NULL,
+  status_type varchar(255) NOT NULL,
+  username varchar(255) NOT NULL,
+  display_name varchar(255) NOT NULL,
+  prev_user varchar(255) DEFAULT NULL,
+  prev_status varchar(255) DEFAULT NULL,
+  prev_notes longtext,
+  date datetime NOT NULL,
+  undone datetime DEFAULT NULL,
+  KEY device_id (device_id)
+);
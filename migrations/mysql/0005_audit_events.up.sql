@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+  id INTEGER UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+  date datetime NOT NULL,
+  username varchar(255) NOT NULL,
+  display_name varchar(255) NOT NULL,
+  action varchar(255) NOT NULL,
+  other_id varchar(255) DEFAULT NULL,
+  device_id INTEGER UNSIGNED DEFAULT NULL,
+  bag_tag varchar(255) DEFAULT NULL,
+  before_value longtext,
+  after_value longtext,
+  request_id varchar(255) DEFAULT NULL,
+  KEY date (date),
+  KEY username (username),
+  KEY other_id (other_id),
+  KEY device_id (device_id),
+  KEY bag_tag (bag_tag)
+);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS payments (
+  id INTEGER UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+  charge_id INTEGER UNSIGNED NOT NULL,
+  other_id varchar(255) NOT NULL,
+  user varchar(255) NOT NULL,
+  amount double NOT NULL,
+  method varchar(255) NOT NULL,
+  receipt_number varchar(255) DEFAULT NULL,
+  username varchar(255) NOT NULL,
+  display_name varchar(255) NOT NULL,
+  date datetime NOT NULL,
+  KEY charge_id (charge_id),
+  KEY other_id (other_id)
+);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS sessions (
+  id char(64) PRIMARY KEY,
+  username varchar(255) NOT NULL,
+  user longtext NOT NULL,
+  expires datetime NOT NULL,
+  KEY username (username),
+  KEY expires (expires)
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+  id INTEGER UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+  name varchar(255) NOT NULL,
+  hash char(64) NOT NULL,
+  scopes varchar(255) NOT NULL,
+  created datetime NOT NULL,
+  expires datetime DEFAULT NULL,
+  last_used datetime DEFAULT NULL,
+  UNIQUE KEY name (name),
+  UNIQUE KEY hash (hash)
+);
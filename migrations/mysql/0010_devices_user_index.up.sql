@@ -0,0 +1 @@
+ALTER TABLE devices ADD KEY user (User);
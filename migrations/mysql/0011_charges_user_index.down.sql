@@ -0,0 +1 @@
+ALTER TABLE charges DROP KEY user;
@@ -0,0 +1 @@
+ALTER TABLE charges ADD KEY user (User);
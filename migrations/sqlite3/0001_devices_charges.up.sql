@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS devices (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  Inventory_Number varchar(255) DEFAULT NULL UNIQUE,
+  Serial_Number varchar(255) DEFAULT NULL,
+  Bag_Tag varchar(255) DEFAULT NULL,
+  status varchar(255) DEFAULT NULL,
+  User varchar(255) DEFAULT NULL COLLATE NOCASE,
+  user_type varchar(255) DEFAULT NULL,
+  device_type varchar(255) DEFAULT NULL,
+  manufacturer varchar(255) DEFAULT NULL,
+  model varchar(255) DEFAULT NULL,
+  Campus varchar(255) DEFAULT NULL,
+  Room varchar(255) DEFAULT NULL,
+  on_network char(1) DEFAULT NULL,
+  notes longtext,
+  po_number varchar(255) DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS devices_bag_tag ON devices (Bag_Tag);
+
+CREATE TABLE IF NOT EXISTS charges (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  Inventory_Number varchar(255) DEFAULT NULL,
+  User varchar(255) DEFAULT NULL COLLATE NOCASE,
+  Amount_Paid double DEFAULT NULL,
+  Charges longtext,
+  Notes longtext
+);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS verifications (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  device_id INTEGER NOT NULL,
+  username varchar(255) NOT NULL,
+  date datetime NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS verifications_device_id ON verifications (device_id);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS loans (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  device_id INTEGER NOT NULL,
+  other_id varchar(255) NOT NULL,
+  user varchar(255) NOT NULL COLLATE NOCASE,
+  username varchar(255) NOT NULL,
+  checked_out datetime NOT NULL,
+  due datetime NOT NULL,
+  returned datetime DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS loans_device_id ON loans (device_id);
+
+CREATE INDEX IF NOT EXISTS loans_other_id ON loans (other_id);
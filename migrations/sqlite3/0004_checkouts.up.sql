@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS checkouts (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  device_id INTEGER NOT NULL,
+  verification_id INTEGER NOT NULL,
+  other_id varchar(255) NOT NULL,
+  user varchar(255) NOT NULL COLLATE NOCASE,
+  status_type varchar(255) NOT NULL,
+  username varchar(255) NOT NULL,
+  display_name varchar(255) NOT NULL,
+  prev_user varchar(255) DEFAULT NULL,
+  prev_status varchar(255) DEFAULT NULL,
+  prev_notes longtext,
+  date datetime NOT NULL,
+  undone datetime DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS checkouts_device_id ON checkouts (device_id);
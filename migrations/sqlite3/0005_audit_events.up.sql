@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  date datetime NOT NULL,
+  username varchar(255) NOT NULL COLLATE NOCASE,
+  display_name varchar(255) NOT NULL,
+  action varchar(255) NOT NULL,
+  other_id varchar(255) DEFAULT NULL,
+  device_id INTEGER DEFAULT NULL,
+  bag_tag varchar(255) DEFAULT NULL,
+  before_value longtext,
+  after_value longtext,
+  request_id varchar(255) DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_date ON audit_events (date);
+
+CREATE INDEX IF NOT EXISTS audit_events_username ON audit_events (username);
+
+CREATE INDEX IF NOT EXISTS audit_events_other_id ON audit_events (other_id);
+
+CREATE INDEX IF NOT EXISTS audit_events_device_id ON audit_events (device_id);
+
+CREATE INDEX IF NOT EXISTS audit_events_bag_tag ON audit_events (bag_tag);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS payments (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  charge_id INTEGER NOT NULL,
+  other_id varchar(255) NOT NULL,
+  user varchar(255) NOT NULL COLLATE NOCASE,
+  amount double NOT NULL,
+  method varchar(255) NOT NULL,
+  receipt_number varchar(255) DEFAULT NULL,
+  username varchar(255) NOT NULL,
+  display_name varchar(255) NOT NULL,
+  date datetime NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS payments_charge_id ON payments (charge_id);
+
+CREATE INDEX IF NOT EXISTS payments_other_id ON payments (other_id);
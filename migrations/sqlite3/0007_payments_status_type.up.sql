@@ -0,0 +1 @@
+ALTER TABLE payments ADD COLUMN status_type varchar(255) DEFAULT NULL;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS sessions (
+  id char(64) PRIMARY KEY,
+  username varchar(255) NOT NULL COLLATE NOCASE,
+  user longtext NOT NULL,
+  expires datetime NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS sessions_username ON sessions (username);
+
+CREATE INDEX IF NOT EXISTS sessions_expires ON sessions (expires);
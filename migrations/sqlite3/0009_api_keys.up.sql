@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name varchar(255) NOT NULL UNIQUE COLLATE NOCASE,
+  hash char(64) NOT NULL UNIQUE,
+  scopes varchar(255) NOT NULL,
+  created datetime NOT NULL,
+  expires datetime DEFAULT NULL,
+  last_used datetime DEFAULT NULL
+);
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS devices_user ON devices (LOWER(RTRIM(User)));
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS charges_user;
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS charges_user ON charges (LOWER(RTRIM(User)));
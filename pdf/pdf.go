@@ -0,0 +1,166 @@
+// Package pdf writes simple text documents as PDF files
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// page layout in points, for US Letter paper
+const (
+	pageWidth     = 612
+	pageHeight    = 792
+	margin        = 72
+	fontSize      = 11
+	headingSize   = 16
+	lineHeight    = 15
+	headingHeight = 24
+	//maxLineLength is the number of characters that fit on a line in Courier at fontSize
+	maxLineLength = 70
+)
+
+// HeadingPrefix marks a line as a heading when it begins a line passed to Write
+const HeadingPrefix = "# "
+
+// line is a single line of text on a page
+type line struct {
+	text    string
+	heading bool
+}
+
+// escape returns s encoded for a PDF string literal. Characters outside of Latin-1 are replaced with "?"
+func escape(s string) string {
+	buf := new(bytes.Buffer)
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r == '\t':
+			buf.WriteString("    ")
+		case r < 0x20 || (r >= 0x7f && r < 0xa0) || r > 0xff:
+			buf.WriteByte('?')
+		default:
+			buf.WriteByte(byte(r))
+		}
+	}
+	return buf.String()
+}
+
+// wrap splits text into lines of at most n characters, breaking on spaces where possible
+func wrap(text string, n int) []string {
+	var lines []string
+	for len([]rune(text)) > n {
+		r := []rune(text)
+		i := strings.LastIndex(string(r[:n]), " ")
+		if i <= 0 {
+			lines = append(lines, string(r[:n]))
+			text = string(r[n:])
+			continue
+		}
+		lines = append(lines, text[:i])
+		text = strings.TrimLeft(text[i:], " ")
+	}
+	return append(lines, text)
+}
+
+// paginate splits text into pages of lines
+func paginate(text string) [][]*line {
+	var (
+		pages [][]*line
+		page  []*line
+		y     = pageHeight - margin
+	)
+
+	add := func(l *line, height int) {
+		if y-height < margin {
+			pages = append(pages, page)
+			page = nil
+			y = pageHeight - margin
+		}
+		page = append(page, l)
+		y -= height
+	}
+
+	for _, t := range strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n") {
+		if strings.HasPrefix(t, HeadingPrefix) {
+			add(&line{text: strings.TrimPrefix(t, HeadingPrefix), heading: true}, headingHeight)
+			continue
+		}
+		for _, w := range wrap(t, maxLineLength) {
+			add(&line{text: w}, lineHeight)
+		}
+	}
+
+	return append(pages, page)
+}
+
+// content returns the content stream for a page of lines
+func content(lines []*line) []byte {
+	buf := new(bytes.Buffer)
+	y := pageHeight - margin
+	for _, l := range lines {
+		font, size, height := "F1", fontSize, lineHeight
+		if l.heading {
+			font, size, height = "F2", headingSize, headingHeight
+		}
+		y -= height
+		if l.text == "" {
+			continue
+		}
+		fmt.Fprintf(buf, "BT /%s %d Tf %d %d Td (%s) Tj ET\n", font, size, margin, y, escape(l.text))
+	}
+	return buf.Bytes()
+}
+
+// Write writes text as a PDF document to w. Each line of text is written on its own line in a fixed-width font,
+// wrapping long lines. Lines beginning with HeadingPrefix are written in a larger, bold font
+func Write(w io.Writer, text string) error {
+	pages := paginate(text)
+
+	var objects [][]byte
+	//add returns the object number of the added object
+	add := func(obj string) int {
+		objects = append(objects, []byte(obj))
+		return len(objects)
+	}
+
+	add("<< /Type /Catalog /Pages 2 0 R >>")
+	add("") //pages is filled in once the page objects are known
+	font := add("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	boldFont := add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	var kids []string
+	for _, p := range pages {
+		stream := content(p)
+		c := add(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream))
+		page := add(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 %d 0 R /F2 %d 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, font, boldFont, c,
+		))
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+	}
+
+	objects[1] = []byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+
+	buf := new(bytes.Buffer)
+	buf.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,66 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEscape(t *testing.T) {
+	if s := escape("Paid (cash) \\ café\t€"); s != "Paid \\(cash\\) \\\\ caf\xe9    ?" {
+		t.Errorf("escape = %q", s)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	for _, test := range []struct {
+		text string
+		want []string
+	}{
+		{"short", []string{"short"}},
+		{"", []string{""}},
+		{"one two three", []string{"one two", "three"}},
+		{"abcdefghijkl", []string{"abcdefghij", "kl"}},
+	} {
+		if lines := wrap(test.text, 10); !reflect.DeepEqual(lines, test.want) {
+			t.Errorf("wrap(%q) = %q, want %q", test.text, lines, test.want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := Write(buf, "# Heading\nBody (text)"); err != nil {
+		t.Fatal("Write returned an error:", err)
+	}
+
+	pdf := buf.String()
+	for _, want := range []string{"%PDF-1.4\n", "/F2 16 Tf 72 696 Td (Heading) Tj", "/F1 11 Tf 72 681 Td (Body \\(text\\)) Tj", "/Count 1", "%%EOF\n"} {
+		if !strings.Contains(pdf, want) {
+			t.Errorf("Write output doesn't contain %q", want)
+		}
+	}
+
+	//the xref offsets point at their objects
+	i := strings.Index(pdf, "xref\n")
+	for n, entry := range strings.Split(pdf[i:], "\n")[3:7] {
+		var offset int
+		if _, err := fmt.Sscanf(entry, "%d", &offset); err != nil {
+			t.Fatalf("Could not parse xref entry %q: %v", entry, err)
+		}
+		if obj := strings.SplitN(pdf[offset:], "\n", 2)[0]; obj != fmt.Sprintf("%d 0 obj", n+1) {
+			t.Errorf("xref entry %d points at %q", n+1, obj)
+		}
+	}
+
+	//long documents are split into pages
+	buf.Reset()
+	if err := Write(buf, strings.Repeat("line\n", 100)); err != nil {
+		t.Fatal("Write returned an error:", err)
+	}
+	if !strings.Contains(buf.String(), "/Count 3") {
+		t.Error("Write of 100 lines didn't write 3 pages")
+	}
+}